@@ -0,0 +1,158 @@
+// Command cs2bot runs the bot's reports and maintenance from a terminal or cron job. It shares the
+// internal package and the config file with the Discord bot but needs only the FACEIT settings, no Discord
+// token. The config file is CONFIG_FILE or ./config.yaml, with the environment on top.
+//
+//	cs2bot report [-window last-week|current-week|<n>d] [-format table|json]
+//	cs2bot players list|add <nickname>|remove <nickname>
+//	cs2bot sync
+//	cs2bot export [-window last|current|<n>d] [-format csv|json|xlsx] [-matches] [-out dir]
+//	cs2bot dashboard [-out dir]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lurker-gaming-cs2-bot/internal"
+)
+
+const usage = `Usage: cs2bot <command> [flags]
+
+Commands:
+  report     print the summary tables of a window
+  players    list, add or remove tracked players
+  sync       take the ELO and ranking snapshots of the hourly refresh, and rebuild the dashboard when dashboard.dir is set
+  export     write a report as CSV, JSON or XLSX files
+  dashboard  render the static stats site
+
+Run "cs2bot <command> -h" for the command's flags.
+`
+
+// The loaded configuration
+var config *internal.Config
+
+func main() {
+	log.SetOutput(os.Stderr)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cfg, err := internal.LoadConfig("")
+	if err == nil {
+		err = cfg.Validate(false)
+	}
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+	internal.Configure(cfg)
+	config = cfg
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "report":
+		report(args)
+	case "players":
+		players(args)
+	case "sync":
+		sync(args)
+	case "export":
+		export(args)
+	case "dashboard":
+		dashboard(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+}
+
+func report(args []string) {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	window := flags.String("window", "last-week", "last-week, current-week or <n>d for the last n days")
+	format := flags.String("format", "table", "table or json")
+	flags.Parse(args)
+
+	out, err := internal.Report(*window, *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(out)
+}
+
+func players(args []string) {
+	flags := flag.NewFlagSet("players", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, "Usage: cs2bot players list|add <nickname>|remove <nickname>") }
+	flags.Parse(args)
+
+	switch {
+	case flags.NArg() == 1 && flags.Arg(0) == "list":
+		fmt.Println(strings.Trim(internal.ListPlayers(""), "`"))
+	case flags.NArg() == 2 && flags.Arg(0) == "add":
+		fmt.Println(internal.AddPlayer(flags.Arg(1)))
+	case flags.NArg() == 2 && flags.Arg(0) == "remove":
+		fmt.Println(internal.RemovePlayer(flags.Arg(1)))
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
+
+// sync does the Discord-independent part of the hourly refresh. Ban checks are left to the bot, since a
+// ban recorded here would never be announced.
+func sync(args []string) {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	flags.Parse(args)
+
+	snapshots := internal.TakeELOSnapshots()
+	fmt.Printf("ELO snapshots taken for %d players\n", len(snapshots))
+	internal.TakeRankSnapshots()
+	fmt.Println("Ranking snapshots up to date")
+	if dir := config.Dashboard.Dir; dir != "" {
+		if err := internal.GenerateDashboard(dir); err != nil {
+			log.Fatal("Error generating dashboard: ", err)
+		}
+		fmt.Println("Dashboard written to", dir)
+	}
+}
+
+func export(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	window := flags.String("window", "last", "current, last or <n>d for the last n days")
+	format := flags.String("format", "csv", "csv, json or xlsx")
+	matches := flags.Bool("matches", false, "include a row per match")
+	out := flags.String("out", ".", "directory to write the files to")
+	flags.Parse(args)
+
+	files, err := internal.Export(strings.TrimSuffix(*window, "-week"), *format, *matches)
+	if err != nil {
+		log.Fatal("Error building export: ", err)
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatal(err)
+	}
+	for _, file := range files {
+		path := filepath.Join(*out, file.Name)
+		if err := os.WriteFile(path, file.Data, 0644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(path)
+	}
+}
+
+func dashboard(args []string) {
+	flags := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	defaultOut := config.Dashboard.Dir
+	if defaultOut == "" {
+		defaultOut = "dashboard"
+	}
+	out := flags.String("out", defaultOut, "directory to write the site to (default dashboard.dir or ./dashboard)")
+	flags.Parse(args)
+
+	if err := internal.GenerateDashboard(*out); err != nil {
+		log.Fatal("Error generating dashboard: ", err)
+	}
+}
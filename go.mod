@@ -6,6 +6,7 @@ require (
 	github.com/bwmarrin/discordgo v0.27.1
 	github.com/joho/godotenv v1.5.1
 	github.com/olekukonko/tablewriter v1.0.9
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
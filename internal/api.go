@@ -0,0 +1,446 @@
+package internal
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The optional HTTP API serving the same numbers as the Discord summaries, configured in the api section of
+// the config file or the environment:
+//
+//	addr          API_ADDR          listen address such as ":8080"; the API is off when empty
+//	key           API_KEY           required key, sent as "X-API-Key: <key>" or "Authorization: Bearer <key>"
+//	cors_origins  API_CORS_ORIGINS  origins allowed to call the API from a browser, "*" for any
+const (
+	apiCacheTTL       = 5 * time.Minute
+	apiMaxWindowDays  = 180
+	apiShutdownWindow = 5 * time.Second
+)
+
+// One summary row, the JSON form of MatchHistory
+type apiRow struct {
+	Position          int     `json:"position,omitempty"`
+	PlayerID          string  `json:"player_id"`
+	Nickname          string  `json:"nickname"`
+	Matches           int     `json:"matches"`
+	Wins              int     `json:"wins"`
+	Losses            int     `json:"losses"`
+	WinRate           float64 `json:"win_rate"`
+	KDRatio           float64 `json:"kd_ratio"`
+	HSPercentage      float64 `json:"hs_percentage"`
+	ADR               float64 `json:"adr"`
+	Kills             int     `json:"kills"`
+	MVPs              int     `json:"mvps"`
+	QuadroKills       int     `json:"quadro_kills"`
+	PentaKills        int     `json:"penta_kills"`
+	CurrentStreak     int     `json:"current_streak"`
+	LongestWinStreak  int     `json:"longest_win_streak"`
+	LongestLossStreak int     `json:"longest_loss_streak"`
+	ELOChange         int     `json:"elo_change"`
+	Banned            bool    `json:"banned"`
+}
+
+type apiWindow struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type apiPlayer struct {
+	PlayerID string   `json:"player_id"`
+	Nickname string   `json:"nickname"`
+	Groups   []string `json:"groups"`
+}
+
+type apiSummary struct {
+	Window apiWindow `json:"window"`
+	Player apiRow    `json:"player"`
+}
+
+type apiReportGroup struct {
+	Name string   `json:"name"` // "" for the default group
+	Rows []apiRow `json:"rows"`
+}
+
+type apiReport struct {
+	Window apiWindow        `json:"window"`
+	Sort   string           `json:"sort"`
+	Groups []apiReportGroup `json:"groups"`
+}
+
+type apiMatchPlayer struct {
+	PlayerID     string  `json:"player_id"`
+	Nickname     string  `json:"nickname"`
+	Tracked      bool    `json:"tracked"`
+	Kills        int     `json:"kills"`
+	Deaths       int     `json:"deaths"`
+	Assists      int     `json:"assists"`
+	KDRatio      float64 `json:"kd_ratio"`
+	ADR          float64 `json:"adr"`
+	HSPercentage float64 `json:"hs_percentage"`
+	MVPs         int     `json:"mvps"`
+}
+
+type apiMatchTeam struct {
+	Name            string           `json:"name"`
+	FinalScore      int              `json:"final_score"`
+	FirstHalfScore  int              `json:"first_half_score"`
+	SecondHalfScore int              `json:"second_half_score"`
+	OvertimeScore   int              `json:"overtime_score"`
+	Players         []apiMatchPlayer `json:"players"`
+}
+
+type apiMatchMap struct {
+	Map   string         `json:"map"`
+	Score string         `json:"score"`
+	Teams []apiMatchTeam `json:"teams"`
+}
+
+type apiMatch struct {
+	MatchID     string        `json:"match_id"`
+	Competition string        `json:"competition"`
+	Status      string        `json:"status"`
+	URL         string        `json:"url"`
+	StartedAt   time.Time     `json:"started_at"`
+	FinishedAt  time.Time     `json:"finished_at,omitzero"`
+	Teams       []string      `json:"teams"`
+	Winner      string        `json:"winner,omitempty"`
+	Maps        []apiMatchMap `json:"maps"`
+}
+
+type apiCacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+var (
+	apiCache   = make(map[string]apiCacheEntry) // key: request path and query
+	apiCacheMu sync.Mutex
+	// ids and nicknames that matched no tracked player, with when to ask FACEIT again
+	apiUnknownPlayers = make(map[string]time.Time) // key: lower-cased id, guarded by apiCacheMu
+)
+
+// toAPIRow converts a summary row; position 0 leaves it out
+func toAPIRow(mh *MatchHistory, position int, banned map[string]BanRecord) apiRow {
+	row := apiRow{
+		PlayerID:          mh.PlayerID,
+		Nickname:          mh.Nickname,
+		Matches:           mh.Total_Matches,
+		Wins:              mh.Total_Wins,
+		Losses:            mh.Total_Losses,
+		KDRatio:           mh.Total_KDRatio,
+		HSPercentage:      mh.Total_HS_Percentage,
+		ADR:               mh.Total_ADR,
+		Kills:             mh.Total_Kills,
+		MVPs:              mh.Total_MVPs,
+		QuadroKills:       mh.Total_Quadro_Kills,
+		PentaKills:        mh.Total_Penta_Kills,
+		CurrentStreak:     mh.Current_Streak,
+		LongestWinStreak:  mh.Longest_Win_Streak,
+		LongestLossStreak: mh.Longest_Loss_Streak,
+		ELOChange:         mh.ELO_Change,
+	}
+	if mh.Total_Matches > 0 {
+		row.Position = position
+		row.WinRate = float64(mh.Total_Wins) / float64(mh.Total_Matches)
+	}
+	_, row.Banned = banned[mh.PlayerID]
+	return row
+}
+
+// resolveReportWindow resolves a window name: "current", "last" or "<n>d" for the last n days, fallback when
+// empty. The returned report slot decides which report filter applies.
+func resolveReportWindow(value, fallback string) (window apiWindow, slot string, err error) {
+	if value == "" {
+		value = fallback
+	}
+	current := CurrentWeekWindow(time.Now())
+	switch value {
+	case "current":
+		return apiWindow{value, current.Start, current.End}, "current-week", nil
+	case "last":
+		last := current.Previous()
+		return apiWindow{value, last.Start, last.End}, "last-week", nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+	if !strings.HasSuffix(value, "d") || err != nil || days < 1 || days > apiMaxWindowDays {
+		return apiWindow{}, "", errors.New("window must be current, last or 1d to " + strconv.Itoa(apiMaxWindowDays) + "d")
+	}
+	end := time.Now().In(reportLocation())
+	return apiWindow{value, end.AddDate(0, 0, -days), end}, "current-week", nil
+}
+
+// findTrackedPlayer resolves a player ID or nickname to a tracked player. A tracked nickname costs one
+// FACEIT call; ids that turn out not to be tracked are remembered for apiCacheTTL, so unknown ids can't
+// be used to spend the FACEIT quota.
+func findTrackedPlayer(id string) (FACEITPlayers, bool) {
+	key := strings.ToLower(id)
+	apiCacheMu.Lock()
+	retry, unknown := apiUnknownPlayers[key]
+	apiCacheMu.Unlock()
+	if unknown && time.Now().Before(retry) {
+		return FACEITPlayers{}, false
+	}
+
+	names := loadPlayerJSON().PlayerName
+	var profile FACEITPlayerProfile
+	var err error
+	if slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, id) }) {
+		profile, err = getPlayerByNickname(id)
+	} else {
+		profile, err = getPlayerByID(id)
+	}
+	if err == nil {
+		for _, name := range names {
+			if strings.EqualFold(name, profile.Nickname) {
+				return FACEITPlayers{PlayerName: name, PlayerID: profile.PlayerID}, true
+			}
+		}
+	}
+	if err == nil || !transientFACEITError(err) {
+		apiCacheMu.Lock()
+		for k, retry := range apiUnknownPlayers {
+			if time.Now().After(retry) {
+				delete(apiUnknownPlayers, k)
+			}
+		}
+		apiUnknownPlayers[key] = time.Now().Add(apiCacheTTL)
+		apiCacheMu.Unlock()
+	}
+	return FACEITPlayers{}, false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Error writing API response", err)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// cached serves a response from the cache, or builds and caches it. Every endpoint calls the FACEIT API
+// once per tracked player, so a website polling the API must not hit FACEIT on every page view.
+func cached(build func(r *http.Request) (interface{}, int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Path + "?" + r.URL.RawQuery
+		apiCacheMu.Lock()
+		entry, ok := apiCache[key]
+		apiCacheMu.Unlock()
+		if !ok || time.Now().After(entry.expires) {
+			v, status, err := build(r)
+			if err != nil {
+				writeAPIError(w, status, err.Error())
+				return
+			}
+			body, err := json.Marshal(v)
+			if err != nil {
+				log.Println("Error encoding API response", err)
+				writeAPIError(w, http.StatusInternalServerError, "could not encode the response")
+				return
+			}
+			entry = apiCacheEntry{body: body, expires: time.Now().Add(apiCacheTTL)}
+			apiCacheMu.Lock()
+			for k, e := range apiCache {
+				if time.Now().After(e.expires) {
+					delete(apiCache, k)
+				}
+			}
+			apiCache[key] = entry
+			apiCacheMu.Unlock()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(time.Until(entry.expires).Seconds())))
+		w.Write(append(entry.body, '\n'))
+	}
+}
+
+func apiPlayers(r *http.Request) (interface{}, int, error) {
+	groupsByName := playerGroupNames(guildGroups(summaryGuildID()))
+	players := []apiPlayer{}
+	for _, player := range getPlayerIDs() {
+		groups := groupsByName[strings.ToLower(player.PlayerName)]
+		if groups == nil {
+			groups = []string{}
+		}
+		players = append(players, apiPlayer{PlayerID: player.PlayerID, Nickname: player.PlayerName, Groups: groups})
+	}
+	return players, http.StatusOK, nil
+}
+
+func apiPlayerSummary(r *http.Request) (interface{}, int, error) {
+	window, slot, err := resolveReportWindow(r.URL.Query().Get("window"), "current")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	player, ok := findTrackedPlayer(r.PathValue("id"))
+	if !ok {
+		return nil, http.StatusNotFound, errors.New("not a tracked player: " + r.PathValue("id"))
+	}
+	filter, err := compileFilter(reportFilterExpression(slot, ""))
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("invalid " + slot + " filter: " + err.Error())
+	}
+	rows := aggregateMatchHistory([]FACEITPlayers{player}, filter, ToUnixMillis(window.Start), ToUnixMillis(window.End))
+	summary := apiSummary{Window: window, Player: apiRow{PlayerID: player.PlayerID, Nickname: player.PlayerName}}
+	for _, mh := range rows {
+		if mh.PlayerID == player.PlayerID {
+			summary.Player = toAPIRow(mh, 0, activeBans())
+		}
+	}
+	return summary, http.StatusOK, nil
+}
+
+// apiWeeklyReport returns every group's summary table, sorted like the Discord message
+func apiWeeklyReport(r *http.Request) (interface{}, int, error) {
+	value := r.URL.Query().Get("window")
+	if value != "" && value != "current" && value != "last" {
+		return nil, http.StatusBadRequest, errors.New("window must be current or last")
+	}
+	window, slot, _ := resolveReportWindow(value, "last")
+	report := apiReport{Window: window, Sort: reportSortKey(slot, ""), Groups: []apiReportGroup{}}
+	banned := activeBans()
+	for _, gr := range buildGroupReports(window, slot, report.Sort) {
+		out := apiReportGroup{Name: gr.Group.Name, Rows: []apiRow{}}
+		for n, mh := range gr.Rows {
+			out.Rows = append(out.Rows, toAPIRow(mh, n+1, banned))
+		}
+		report.Groups = append(report.Groups, out)
+	}
+	return report, http.StatusOK, nil
+}
+
+func apiMatchDetails(r *http.Request) (interface{}, int, error) {
+	matchID := parseMatchID(r.PathValue("id"))
+	match, err := getMatch(matchID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.New("match not found on FACEIT: " + matchID)
+	}
+	out := apiMatch{
+		MatchID:     match.MatchID,
+		Competition: match.CompetitionName,
+		Status:      match.Status,
+		URL:         strings.Replace(match.FaceitURL, "{lang}", "en", 1),
+		StartedAt:   time.Unix(match.StartedAt, 0),
+		Teams:       []string{match.Teams["faction1"].Name, match.Teams["faction2"].Name},
+		Winner:      match.Teams[match.Results.Winner].Name,
+		Maps:        []apiMatchMap{},
+	}
+	if match.FinishedAt > 0 {
+		out.FinishedAt = time.Unix(match.FinishedAt, 0)
+	}
+	if match.Status != "FINISHED" {
+		return out, http.StatusOK, nil
+	}
+	stats, err := getMatchStats(matchID)
+	if err != nil {
+		log.Println("Error getting match stats", matchID, err)
+		return out, http.StatusOK, nil
+	}
+	tracked := trackedNicknames()
+	for _, round := range stats.Rounds {
+		m := apiMatchMap{Map: round.RoundStats.Map, Score: round.RoundStats.Score}
+		for _, team := range round.Teams {
+			ts := team.TeamStats
+			t := apiMatchTeam{
+				Name:            ts.Team,
+				FinalScore:      ts.FinalScore,
+				FirstHalfScore:  ts.FirstHalfScore,
+				SecondHalfScore: ts.SecondHalfScore,
+				OvertimeScore:   ts.OvertimeScore,
+			}
+			for _, player := range team.Players {
+				ps := player.PlayerStats
+				t.Players = append(t.Players, apiMatchPlayer{
+					PlayerID:     player.PlayerID,
+					Nickname:     player.Nickname,
+					Tracked:      tracked[strings.ToLower(player.Nickname)],
+					Kills:        ps.Kills,
+					Deaths:       ps.Deaths,
+					Assists:      ps.Assists,
+					KDRatio:      ps.KDRatio,
+					ADR:          ps.ADR,
+					HSPercentage: ps.HeadshotsPercentage,
+					MVPs:         ps.MVPs,
+				})
+			}
+			m.Teams = append(m.Teams, t)
+		}
+		out.Maps = append(out.Maps, m)
+	}
+	return out, http.StatusOK, nil
+}
+
+// apiMiddleware answers CORS preflights and rejects requests without the API key
+func apiMiddleware(next http.Handler, key string, origins []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			for _, allowed := range origins {
+				if allowed == "*" || strings.EqualFold(allowed, origin) {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+					w.Header().Set("Access-Control-Allow-Headers", "Authorization, X-API-Key")
+					w.Header().Set("Access-Control-Max-Age", "600")
+					break
+				}
+			}
+			w.Header().Add("Vary", "Origin")
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		given := r.Header.Get("X-API-Key")
+		if given == "" {
+			given = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(key)) != 1 {
+			writeAPIError(w, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// StartAPIServer serves the HTTP API until stopCh is closed. It returns right away when no api.addr is
+// configured; Config.Validate makes sure a key is set when one is.
+func StartAPIServer(stopCh <-chan struct{}) {
+	addr, key, origins := config.API.Addr, config.API.Key, config.API.CORSOrigins
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/players", cached(apiPlayers))
+	mux.Handle("GET /api/players/{id}/summary", cached(apiPlayerSummary))
+	mux.Handle("GET /api/reports/weekly", cached(apiWeeklyReport))
+	mux.Handle("GET /api/matches/{id}", cached(apiMatchDetails))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           apiMiddleware(mux, key, origins),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), apiShutdownWindow)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	log.Println("HTTP API listening on", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Println("HTTP API stopped:", err)
+	}
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord accepts at most 25 autocomplete choices
+const maxAutocompleteChoices = 25
+
+// Suggestion sources for player name options, keyed by command name
+var playerAutocomplete = map[string]func(query string) []*discordgo.ApplicationCommandOptionChoice{
+	"add-player":    faceitSearchChoices,
+	"remove-player": trackedPlayerChoices,
+	"link":          faceitSearchChoices,
+	"group":         trackedPlayerChoices,
+	"rank":          faceitSearchChoices,
+	"history":       trackedPlayerChoices,
+	"profile":       faceitSearchChoices,
+	"trend":         trackedPlayerChoices,
+}
+
+// Option names that hold a FACEIT nickname
+var playerOptionNames = map[string]bool{"name": true, "player": true, "player2": true, "player3": true}
+
+// Wrapper for the FACEIT /search/players response
+type playerSearchList struct {
+	Items []struct {
+		PlayerID string `json:"player_id"`
+		Nickname string `json:"nickname"`
+		Country  string `json:"country"`
+		Games    []struct {
+			Name       string `json:"name"`
+			SkillLevel string `json:"skill_level"`
+		} `json:"games"`
+	} `json:"items"`
+}
+
+// trackedPlayerChoices suggests tracked players whose nickname contains query
+func trackedPlayerChoices(query string) []*discordgo.ApplicationCommandOptionChoice {
+	names := loadPlayerJSON().PlayerName
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	query = strings.ToLower(query)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, name := range names {
+		if !strings.Contains(strings.ToLower(name), query) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+	}
+	return choices
+}
+
+// faceitSearchChoices suggests CS2 players from FACEIT's nickname search
+func faceitSearchChoices(query string) []*discordgo.ApplicationCommandOptionChoice {
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+	var list playerSearchList
+	if err := getFACEIT("/search/players", map[string]interface{}{
+		"nickname": query,
+		"game":     "cs2",
+		"offset":   0,
+		"limit":    maxAutocompleteChoices,
+	}, &list); err != nil {
+		log.Printf("FACEIT player search for %q failed: %v", query, err)
+		return nil
+	}
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, item := range list.Items {
+		label := item.Nickname
+		for _, game := range item.Games {
+			if game.Name == "cs2" && game.SkillLevel != "" {
+				label += " (lvl " + game.SkillLevel + ")"
+			}
+		}
+		if item.Country != "" {
+			label += " " + strings.ToUpper(item.Country)
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: label, Value: item.Nickname})
+	}
+	return choices
+}
+
+// focusedOption returns the option the user is currently typing in, searching through subcommands
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range options {
+		if opt.Focused {
+			return opt
+		}
+		if found := focusedOption(opt.Options); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	focused := focusedOption(data.Options)
+	if focused == nil {
+		return
+	}
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	if focused.Name == "group" {
+		choices = append(choices, groupChoices(i.GuildID, focused.StringValue())...)
+	} else if source, ok := playerAutocomplete[data.Name]; ok && playerOptionNames[focused.Name] {
+		choices = append(choices, source(focused.StringValue())...)
+	}
+	if err := interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	}); err != nil {
+		log.Printf("failed to respond to autocomplete: %v", err)
+	}
+}
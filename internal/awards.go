@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Per-guild awards configuration. The zero value shows every award with the default match threshold.
+type AwardSettings struct {
+	Disabled   bool     `json:"disabled,omitempty"`
+	MinMatches int      `json:"min_matches,omitempty"` // 0 means defaultAwardMinMatches
+	Off        []string `json:"off,omitempty"`         // keys of individual awards that are turned off
+}
+
+// A superlative computed from a report window's rows. Only rows with a value of at least Min can win.
+type award struct {
+	Key    string
+	Title  string
+	Min    float64
+	Value  func(mh *MatchHistory) float64
+	Format func(mh *MatchHistory, value float64) string
+}
+
+const defaultAwardMinMatches = 3
+
+var awards = []award{
+	{
+		Key: "top-fragger", Title: "Top Fragger", Min: 1,
+		Value:  func(mh *MatchHistory) float64 { return float64(mh.Total_Kills) },
+		Format: func(_ *MatchHistory, v float64) string { return fmt.Sprintf("%.0f kills", v) },
+	},
+	{
+		Key: "best-adr", Title: "Best ADR", Min: 1,
+		Value:  func(mh *MatchHistory) float64 { return mh.Total_ADR },
+		Format: func(_ *MatchHistory, v float64) string { return fmt.Sprintf("%.1f ADR", v) },
+	},
+	{
+		Key: "headshots", Title: "Highest HS%", Min: 1,
+		Value:  func(mh *MatchHistory) float64 { return mh.Total_HS_Percentage },
+		Format: func(_ *MatchHistory, v float64) string { return fmt.Sprintf("%.1f%% headshots", v) },
+	},
+	{
+		Key: "multi-kills", Title: "Most Multi-Kills", Min: 1,
+		Value: func(mh *MatchHistory) float64 {
+			return float64(mh.Total_Quadro_Kills + mh.Total_Penta_Kills)
+		},
+		Format: func(mh *MatchHistory, _ float64) string {
+			return fmt.Sprintf("%d 4K, %d 5K", mh.Total_Quadro_Kills, mh.Total_Penta_Kills)
+		},
+	},
+	{
+		Key: "elo-gain", Title: "Biggest ELO Gain", Min: 1,
+		Value:  func(mh *MatchHistory) float64 { return float64(mh.ELO_Change) },
+		Format: func(_ *MatchHistory, v float64) string { return fmt.Sprintf("+%.0f ELO", v) },
+	},
+	{
+		Key: "tilt", Title: "Worst Tilt Streak", Min: 3,
+		Value:  func(mh *MatchHistory) float64 { return float64(mh.Longest_Loss_Streak) },
+		Format: func(_ *MatchHistory, v float64) string { return fmt.Sprintf("%.0f losses in a row", v) },
+	},
+	{
+		Key: "mvps", Title: "Most MVPs", Min: 1,
+		Value:  func(mh *MatchHistory) float64 { return float64(mh.Total_MVPs) },
+		Format: func(_ *MatchHistory, v float64) string { return fmt.Sprintf("%.0f MVPs", v) },
+	},
+}
+
+// minMatches returns the configured threshold, falling back to the default
+func (settings AwardSettings) minMatches() int {
+	if settings.MinMatches > 0 {
+		return settings.MinMatches
+	}
+	return defaultAwardMinMatches
+}
+
+func (settings AwardSettings) enabled(key string) bool {
+	for _, off := range settings.Off {
+		if off == key {
+			return false
+		}
+	}
+	return true
+}
+
+// renderAwards returns the awards section for a window's rows, or "" when disabled or nobody qualifies.
+// Ties go to the row listed first in the summary.
+func renderAwards(rows []*MatchHistory, settings AwardSettings) string {
+	if settings.Disabled {
+		return ""
+	}
+	var lines []string
+	for _, a := range awards {
+		if !settings.enabled(a.Key) {
+			continue
+		}
+		var winner *MatchHistory
+		best := 0.0
+		for _, mh := range rows {
+			if mh.Total_Matches < settings.minMatches() {
+				continue
+			}
+			if v := a.Value(mh); v >= a.Min && v > best {
+				winner, best = mh, v
+			}
+		}
+		if winner != nil {
+			lines = append(lines, fmt.Sprintf("**%s**: %s (%s)", a.Title, winner.Nickname, a.Format(winner, best)))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "**Awards**\n" + strings.Join(lines, "\n")
+}
+
+func renderAwardSettings(settings AwardSettings) string {
+	status := "enabled"
+	if settings.Disabled {
+		status = "disabled"
+	}
+	lines := []string{fmt.Sprintf("Awards are **%s**, minimum %d matches.", status, settings.minMatches())}
+	for _, a := range awards {
+		state := "on"
+		if !settings.enabled(a.Key) {
+			state = "off"
+		}
+		lines = append(lines, fmt.Sprintf("`%s` %s: %s", a.Key, a.Title, state))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// awardChoices lists the awards for the /awards toggle option
+func awardChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, a := range awards {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: a.Title, Value: a.Key})
+	}
+	return choices
+}
+
+func handleAwards(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionsByName(sub.Options)
+	var update func(settings *AwardSettings)
+	switch sub.Name {
+	case "settings":
+		update = func(settings *AwardSettings) {
+			if opt, ok := opts["enabled"]; ok {
+				settings.Disabled = !opt.BoolValue()
+			}
+			if opt, ok := opts["min_matches"]; ok {
+				settings.MinMatches = int(opt.IntValue())
+			}
+		}
+	case "toggle":
+		key := opts["award"].StringValue()
+		update = func(settings *AwardSettings) {
+			off := settings.Off[:0]
+			for _, k := range settings.Off {
+				if k != key {
+					off = append(off, k)
+				}
+			}
+			if !opts["enabled"].BoolValue() {
+				off = append(off, key)
+			}
+			settings.Off = off
+		}
+	case "list":
+		respondEphemeral(s, i, renderAwardSettings(getGuildSettings(i.GuildID).Awards))
+		return
+	}
+	if err := updateGuildSettings(i.GuildID, func(settings *GuildSettings) { update(&settings.Awards) }); err != nil {
+		log.Println("Error saving guild settings", err)
+		respondEphemeral(s, i, "Could not save the award settings, try again later.")
+		return
+	}
+	respondEphemeral(s, i, renderAwardSettings(getGuildSettings(i.GuildID).Awards))
+}
@@ -0,0 +1,258 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/olekukonko/tablewriter"
+)
+
+// A FACEIT ban of a tracked player, as returned by /players/{player_id}/bans, plus when we first saw it
+type BanRecord struct {
+	PlayerID  string `json:"user_id"`
+	Nickname  string `json:"nickname"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	Game      string `json:"game"`
+	StartsAt  string `json:"starts_at"`
+	EndsAt    string `json:"ends_at"` // empty for permanent bans
+	FirstSeen int64  `json:"first_seen,omitempty"`
+}
+
+// This struct is the layout of data/bans.json
+type banHistory struct {
+	Bans    []BanRecord      `json:"bans"`
+	Checked map[string]int64 `json:"checked"` // key: player ID, value: last check
+}
+
+// Wrapper for the FACEIT /players/{player_id}/bans response
+type playerBansList struct {
+	Items []BanRecord `json:"items"`
+}
+
+const (
+	bansFile         = "bans.json"
+	bannedLabel      = " [BANNED]"
+	banHistoryLength = 20
+)
+
+var bansMu sync.Mutex
+
+func loadBanHistory() banHistory {
+	history := banHistory{Bans: []BanRecord{}, Checked: map[string]int64{}}
+	if err := readDataFile(bansFile, &history); err != nil {
+		log.Println("Error reading", bansFile, err)
+	}
+	if history.Checked == nil {
+		history.Checked = map[string]int64{}
+	}
+	return history
+}
+
+func (ban BanRecord) key() string {
+	return ban.PlayerID + "|" + ban.Type + "|" + ban.StartsAt
+}
+
+// active reports whether the ban has not ended yet. Bans without an end date are permanent.
+func (ban BanRecord) active(now time.Time) bool {
+	if ban.EndsAt == "" {
+		return true
+	}
+	ends, err := time.Parse(time.RFC3339, ban.EndsAt)
+	if err != nil {
+		return true
+	}
+	return ends.After(now)
+}
+
+// describe renders the ban for alerts and /bans list
+func (ban BanRecord) describe() string {
+	until := "permanent"
+	if ban.EndsAt != "" {
+		until = "until " + ban.EndsAt
+	}
+	text := fmt.Sprintf("**%s**: %s ban (%s)", ban.Nickname, ban.Type, until)
+	if ban.Reason != "" {
+		text += " — " + ban.Reason
+	}
+	return text
+}
+
+func getPlayerBans(playerID string) ([]BanRecord, error) {
+	var list playerBansList
+	err := getFACEIT("/players/"+url.PathEscape(playerID)+"/bans", map[string]interface{}{
+		"offset": 0,
+		"limit":  100,
+	}, &list)
+	for i := range list.Items {
+		list.Items[i].PlayerID = playerID
+	}
+	return list.Items, err
+}
+
+// activeBans returns the active bans of tracked players keyed by player ID
+func activeBans() map[string]BanRecord {
+	bansMu.Lock()
+	defer bansMu.Unlock()
+	now := time.Now()
+	active := make(map[string]BanRecord)
+	for _, ban := range loadBanHistory().Bans {
+		if ban.active(now) {
+			active[ban.PlayerID] = ban
+		}
+	}
+	return active
+}
+
+// CheckBans records new bans of the tracked players and returns them. A player's existing bans are recorded
+// silently the first time the player is checked, so adding a player with an old ban does not raise an alert.
+func CheckBans() []BanRecord {
+	type result struct {
+		playerID string
+		bans     []BanRecord
+	}
+	var results []result
+	for _, player := range getPlayerIDs() {
+		bans, err := getPlayerBans(player.PlayerID)
+		if err != nil {
+			log.Printf("Error getting bans for %s: %v ... Continuing", player.PlayerName, err)
+			continue
+		}
+		for i := range bans {
+			if bans[i].Nickname == "" {
+				bans[i].Nickname = player.PlayerName
+			}
+		}
+		results = append(results, result{player.PlayerID, bans})
+	}
+
+	bansMu.Lock()
+	defer bansMu.Unlock()
+	history := loadBanHistory()
+	known := make(map[string]bool)
+	for _, ban := range history.Bans {
+		known[ban.key()] = true
+	}
+	now := time.Now().Unix()
+	var fresh []BanRecord
+	for _, r := range results {
+		_, checkedBefore := history.Checked[r.playerID]
+		for _, ban := range r.bans {
+			if known[ban.key()] {
+				continue
+			}
+			ban.FirstSeen = now
+			history.Bans = append(history.Bans, ban)
+			known[ban.key()] = true
+			if checkedBefore {
+				fresh = append(fresh, ban)
+			}
+		}
+		history.Checked[r.playerID] = now
+	}
+	if err := writeDataFile(bansFile, history); err != nil {
+		log.Println("Error writing", bansFile, err)
+	}
+	return fresh
+}
+
+// AlertNewBans posts new bans to every guild that configured a ban alert channel
+func AlertNewBans(s *discordgo.Session, bans []BanRecord) {
+	if len(bans) == 0 {
+		return
+	}
+	var lines []string
+	for _, ban := range bans {
+		lines = append(lines, ban.describe())
+	}
+	msg := truncateMessage("**New FACEIT ban**\n" + strings.Join(lines, "\n"))
+	for id, settings := range allGuildSettings() {
+		if settings.BanAlertChannelID == "" {
+			continue
+		}
+		if err := postMessage(s, settings.BanAlertChannelID, msg); err != nil {
+			log.Printf("Ban alert for guild %s failed: %v", id, err)
+		}
+	}
+}
+
+// ListBans renders the most recent recorded bans, newest first
+func ListBans() string {
+	bansMu.Lock()
+	history := loadBanHistory()
+	bansMu.Unlock()
+	if len(history.Bans) == 0 {
+		return "No bans recorded for the tracked players."
+	}
+	sort.Slice(history.Bans, func(i, j int) bool { return history.Bans[i].StartsAt > history.Bans[j].StartsAt })
+	if len(history.Bans) > banHistoryLength {
+		history.Bans = history.Bans[:banHistoryLength]
+	}
+	now := time.Now()
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	table.Header([]string{"NAME", "TYPE", "START", "END", "ACTIVE"})
+	for _, ban := range history.Bans {
+		active := "no"
+		if ban.active(now) {
+			active = "yes"
+		}
+		end := ban.EndsAt
+		if end == "" {
+			end = "permanent"
+		}
+		table.Append([]string{ban.Nickname, ban.Type, ban.StartsAt, end, active})
+	}
+	table.Render()
+	return "```" + builder.String() + "```"
+}
+
+func handleBans(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionsByName(sub.Options)
+	switch sub.Name {
+	case "channel":
+		channelID := ""
+		if opt, ok := opts["channel"]; ok {
+			channelID = opt.ChannelValue(nil).ID
+		}
+		if err := updateGuildSettings(i.GuildID, func(settings *GuildSettings) { settings.BanAlertChannelID = channelID }); err != nil {
+			log.Println("Error saving guild settings", err)
+			respondEphemeral(s, i, "Could not save the ban alert channel, try again later.")
+			return
+		}
+		if channelID == "" {
+			respondEphemeral(s, i, "Ban alerts disabled.")
+			return
+		}
+		respondEphemeral(s, i, "New bans of tracked players are posted in <#"+channelID+">.")
+	case "list":
+		respondEphemeral(s, i, truncateMessage(ListBans()))
+	case "check":
+		interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		})
+		go func() {
+			fresh := CheckBans()
+			AlertNewBans(s, fresh)
+			content := fmt.Sprintf("Ban check done, %d new ban(s).", len(fresh))
+			if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
+				Content: &content,
+			}); err != nil {
+				log.Printf("failed to edit response: %v", err)
+			}
+		}()
+	}
+}
@@ -5,20 +5,38 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 var responded sync.Map
 
+// Message component (button) handlers, keyed by the first segment of the custom ID
+var componentHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate, args []string){
+	"add-player": handleAddPlayerButton,
+	"match":      handleMatchButton,
+	"history":    handleHistoryButton,
+}
+
 func RegisterSlashCommands(s *discordgo.Session) {
 	permManageGuild := int64(discordgo.PermissionManageServer)
 	dmDisabled := false
+	minLevel, minELO, minSeason := float64(1), float64(0), float64(1)
+	minWins := float64(2)
 
 	commands := []*discordgo.ApplicationCommand{
 		{
 			Name:        "refresh",
 			Description: "Refreshes the current and last week's FACEIT statistics for all listed players",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "sort",
+					Description: "Sort the summary tables by this until the next hourly refresh",
+					Choices:     sortChoices(),
+				},
+			},
 		},
 		{
 			Name:                     "list-players",
@@ -33,10 +51,11 @@ func RegisterSlashCommands(s *discordgo.Session) {
 			DMPermission:             &dmDisabled,
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "name",
-					Description: "The name of the player to ADD",
-					Required:    true,
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "name",
+					Description:  "The name of the player to ADD",
+					Required:     true,
+					Autocomplete: true,
 				},
 			},
 		},
@@ -45,19 +64,713 @@ func RegisterSlashCommands(s *discordgo.Session) {
 			Description:              "Removes a player from the list of players being tracked",
 			DefaultMemberPermissions: &permManageGuild,
 			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "name",
+					Description:  "The name of the player to REMOVE",
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:         "link",
+			Description:  "Links your Discord account to your FACEIT account (requires verification)",
+			DMPermission: &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "name",
+					Description:  "Your FACEIT nickname",
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:         "unlink",
+			Description:  "Removes the link between your Discord and FACEIT accounts",
+			DMPermission: &dmDisabled,
+		},
+		{
+			Name:                     "roles",
+			Description:              "Configures Discord roles assigned from FACEIT skill level and ELO",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set-level",
+					Description: "Assigns a role to a FACEIT skill level",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "level",
+							Description: "FACEIT skill level (1-10)",
+							Required:    true,
+							MinValue:    &minLevel,
+							MaxValue:    10,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionRole,
+							Name:        "role",
+							Description: "The role to assign",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set-elo-band",
+					Description: "Assigns a role to an ELO range",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionRole,
+							Name:        "role",
+							Description: "The role to assign",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "min",
+							Description: "Lowest ELO in the band",
+							Required:    true,
+							MinValue:    &minELO,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "max",
+							Description: "Highest ELO in the band (leave empty or 0 for no upper bound)",
+							MinValue:    &minELO,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "unset",
+					Description: "Stops managing a role",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionRole,
+							Name:        "role",
+							Description: "The role to stop managing",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Shows the current level and ELO role mapping",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "preview",
+					Description: "Dry run: shows which roles a sync would add or remove",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "sync",
+					Description: "Takes fresh ELO snapshots and syncs roles now",
+				},
+			},
+		},
+		{
+			Name:                     "roster",
+			Description:              "Bulk import or export the tracked players",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "import",
+					Description: "Adds players from a JSON/CSV file, a FACEIT team or a FACEIT hub",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        "file",
+							Description: "JSON or CSV file of FACEIT nicknames",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "team_id",
+							Description: "FACEIT team ID to import members from",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "hub_id",
+							Description: "FACEIT hub ID to import members from",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "export",
+					Description: "Exports the tracked players with their FACEIT IDs",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "format",
+							Description: "File format (default json)",
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "json", Value: "json"},
+								{Name: "csv", Value: "csv"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "group",
+			Description:              "Manages player groups, each with its own summary tables",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "create",
+					Description: "Creates a group",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "group",
+							Description: "Group name, e.g. Main or Academy",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "filter",
+							Description: "Match filter expression or preset, e.g. pugs-only or mode=5v5",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "delete",
+					Description: "Deletes a group (its players stay tracked)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "group",
+							Description:  "Group name",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Adds a tracked player to a group",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "group",
+							Description:  "Group name",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "player",
+							Description:  "Tracked player name",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Removes a player from a group",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "group",
+							Description:  "Group name",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "player",
+							Description:  "Tracked player name",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Lists groups and their members",
+				},
+			},
+		},
+
+		{
+			Name:                     "filter",
+			Description:              "Configures which matches count towards reports",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "report",
+					Description: "Sets the filter of a report",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "report",
+							Description: "Report slot",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "last-week", Value: "last-week"},
+								{Name: "current-week", Value: "current-week"},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "expression",
+							Description: "Filter expression or preset name; leave empty to clear",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "group",
+					Description: "Sets the filter of a player group",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "group",
+							Description:  "Group name",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "expression",
+							Description: "Filter expression or preset name; leave empty to clear",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "preset",
+					Description: "Saves a named filter preset",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Preset name, usable as a bare word in expressions",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "expression",
+							Description: "Filter expression or preset name; leave empty to clear",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Shows report and group filters and the presets",
+				},
+			},
+		},
+
+		{
+			Name:                     "league",
+			Description:              "Configures the league/championship report",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Sets the championship (and optional league season) to report on",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "championship",
+							Description: "FACEIT championship ID",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "team",
+							Description: "Our team's name in the championship (defaults to TEAM_NAME)",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "league_id",
+							Description: "FACEIT league ID, for player divisions and points",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "season_id",
+							Description: "FACEIT league season ID, for player divisions and points",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Shows the league report now",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "clear",
+					Description: "Disables the league report",
+				},
+			},
+		},
+		{
+			Name:         "hub",
+			Description:  "FACEIT hub leaderboard, matches and member stats",
+			DMPermission: &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Sets this server's FACEIT hub (requires Manage Server)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "hub",
+							Description: "FACEIT hub ID or hub URL",
+							Required:    true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "standings_channel",
+							Description:  "Channel for an hourly hub standings message (omit to disable)",
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "clear",
+					Description: "Removes this server's FACEIT hub (requires Manage Server)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "leaderboard",
+					Description: "Shows the hub leaderboard",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "season",
+							Description: "Season number (defaults to the overall leaderboard)",
+							MinValue:    &minSeason,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "recent-matches",
+					Description: "Shows the hub's most recent matches",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "stats",
+					Description: "Shows hub stats for the tracked players",
+				},
+			},
+		},
+		{
+			Name:         "rank",
+			Description:  "Shows regional and country FACEIT rankings",
+			DMPermission: &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "player",
+					Description:  "FACEIT nickname (defaults to all tracked players)",
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:                     "bans",
+			Description:              "FACEIT ban monitoring for tracked players",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "channel",
+					Description: "Sets the channel for new ban alerts (omit to disable)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Alert channel",
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Shows the recorded bans of tracked players",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "check",
+					Description: "Checks the tracked players for new bans now",
+				},
+			},
+		},
+		{
+			Name:         "match",
+			Description:  "Shows the full scoreboard of a FACEIT match",
+			DMPermission: &dmDisabled,
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "name",
-					Description: "The name of the player to REMOVE",
+					Name:        "id",
+					Description: "FACEIT match ID or match room URL",
 					Required:    true,
 				},
 			},
 		},
+		{
+			Name:         "history",
+			Description:  "Shows a player's recent matches",
+			DMPermission: &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "player",
+					Description:  "FACEIT nickname",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "Number of matches (default 20)",
+					MinValue:    &minLevel,
+					MaxValue:    historyMaxCount,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "filter",
+					Description: "Match filter expression or preset, e.g. map=de_mirage",
+				},
+			},
+		},
+		{
+			Name:                     "awards",
+			Description:              "Configures the awards section of the weekly summary",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "settings",
+					Description: "Turns the awards on or off and sets the minimum matches to qualify",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Show the awards section",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "min_matches",
+							Description: "Matches a player needs in the week to win an award (default 3)",
+							MinValue:    &minLevel,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "toggle",
+					Description: "Turns a single award on or off",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "award",
+							Description: "Award",
+							Required:    true,
+							Choices:     awardChoices(),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Show this award",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Shows the award settings",
+				},
+			},
+		},
+		{
+			Name:         "profile",
+			Description:  "Shows a player's FACEIT profile, recent form and streaks",
+			DMPermission: &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "player",
+					Description:  "FACEIT nickname",
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:                     "streak-alerts",
+			Description:              "Announces win streaks of tracked players in the update channel",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "min_wins",
+					Description: "Wins in a row to announce, e.g. 5 (omit to disable)",
+					MinValue:    &minWins,
+				},
+			},
+		},
+		{
+			Name:         "trend",
+			Description:  "Charts a player's ELO, K/D, ADR or HS% over time",
+			DMPermission: &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "player",
+					Description:  "FACEIT nickname",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "metric",
+					Description: "What to chart",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "ELO", Value: "elo"},
+						{Name: "K/D", Value: "kd"},
+						{Name: "ADR", Value: "adr"},
+						{Name: "HS%", Value: "hs"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "range",
+					Description: "Time range (default 30 days)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "7 days", Value: "7d"},
+						{Name: "30 days", Value: "30d"},
+						{Name: "90 days", Value: "90d"},
+						{Name: "180 days", Value: "180d"},
+					},
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "player2",
+					Description:  "Another player to overlay",
+					Autocomplete: true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "player3",
+					Description:  "Another player to overlay",
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:                     "sort",
+			Description:              "Sets the sort order of a summary table",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "report",
+					Description: "Report slot",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "last-week", Value: "last-week"},
+						{Name: "current-week", Value: "current-week"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "by",
+					Description: "Sort order",
+					Required:    true,
+					Choices:     sortChoices(),
+				},
+			},
+		},
+		{
+			Name:                     "deltas",
+			Description:              "Shows week-over-week K/D, ADR and win rate changes in the current-week table",
+			DefaultMemberPermissions: &permManageGuild,
+			DMPermission:             &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Show the change columns",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:         "export",
+			Description:  "Exports a report's summary rows, and optionally every match, as a file",
+			DMPermission: &dmDisabled,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "window",
+					Description: "Time window",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Current week", Value: "current"},
+						{Name: "Last week", Value: "last"},
+						{Name: "Last 30 days", Value: "30d"},
+						{Name: "Last 90 days", Value: "90d"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "format",
+					Description: "File format (default CSV)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "CSV", Value: "csv"},
+						{Name: "JSON", Value: "json"},
+						{Name: "Excel (XLSX)", Value: "xlsx"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "matches",
+					Description: "Include a row per match",
+				},
+			},
+		},
 	}
 	registeredCmds := make([]*discordgo.ApplicationCommand, len(commands))
 	for i, v := range commands {
-		cmd, err := s.ApplicationCommandCreate(applicationID, guildID, v)
+		cmd, err := s.ApplicationCommandCreate(config.Discord.AppID, config.Discord.GuildID, v)
 		if err != nil {
 			log.Panicf("Cannot create '%v' command: %v", v.Name, err)
 		}
@@ -68,16 +781,20 @@ func RegisterSlashCommands(s *discordgo.Session) {
 	commandHandlers := map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){
 		"refresh": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			content := ""
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 				Data: &discordgo.InteractionResponseData{
 					Flags:   discordgo.MessageFlagsEphemeral,
 					Content: "refreshing...",
 				},
 			})
+			sortKey := ""
+			if opt, ok := optionsByName(i.ApplicationCommandData().Options)["sort"]; ok {
+				sortKey = opt.StringValue()
+			}
 			go func() {
-				content = FACEITInit(s)
-				if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				content = FACEITInit(s, sortKey)
+				if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
 					Content: &content,
 				}); err != nil {
 					log.Printf("failed to edit response: %v", err)
@@ -86,7 +803,7 @@ func RegisterSlashCommands(s *discordgo.Session) {
 		},
 		"list-players": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			if !hasManageGuildPermission(i) {
-				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseChannelMessageWithSource,
 					Data: &discordgo.InteractionResponseData{
 						Flags:   discordgo.MessageFlagsEphemeral,
@@ -96,29 +813,8 @@ func RegisterSlashCommands(s *discordgo.Session) {
 				return
 			}
 			content := ""
-			content = ListPlayers()
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Flags:   discordgo.MessageFlagsEphemeral,
-					Content: content,
-				},
-			})
-		},
-		"add-player": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-			if !hasManageGuildPermission(i) {
-				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseChannelMessageWithSource,
-					Data: &discordgo.InteractionResponseData{
-						Flags:   discordgo.MessageFlagsEphemeral,
-						Content: "You do not have permission to use this command.",
-					},
-				})
-				return
-			}
-			content := ""
-			content = AddPlayer(i.ApplicationCommandData().Options[0].StringValue())
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			content = ListPlayers(i.GuildID)
+			interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
 				Data: &discordgo.InteractionResponseData{
 					Flags:   discordgo.MessageFlagsEphemeral,
@@ -126,9 +822,10 @@ func RegisterSlashCommands(s *discordgo.Session) {
 				},
 			})
 		},
+		"add-player": handleAddPlayer,
 		"remove-player": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			if !hasManageGuildPermission(i) {
-				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseChannelMessageWithSource,
 					Data: &discordgo.InteractionResponseData{
 						Flags:   discordgo.MessageFlagsEphemeral,
@@ -139,7 +836,7 @@ func RegisterSlashCommands(s *discordgo.Session) {
 			}
 			content := ""
 			content = RemovePlayer(i.ApplicationCommandData().Options[0].StringValue())
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
 				Data: &discordgo.InteractionResponseData{
 					Flags:   discordgo.MessageFlagsEphemeral,
@@ -147,10 +844,40 @@ func RegisterSlashCommands(s *discordgo.Session) {
 				},
 			})
 		},
+		"link":          handleLink,
+		"unlink":        handleUnlink,
+		"roles":         handleRoles,
+		"roster":        handleRoster,
+		"group":         handleGroup,
+		"filter":        handleFilter,
+		"league":        handleLeague,
+		"hub":           handleHub,
+		"rank":          handleRank,
+		"bans":          handleBans,
+		"match":         handleMatch,
+		"history":       handleHistory,
+		"awards":        handleAwards,
+		"profile":       handleProfile,
+		"streak-alerts": handleStreakAlerts,
+		"trend":         handleTrend,
+		"sort":          handleSort,
+		"deltas":        handleDeltas,
+		"export":        handleExport,
 	}
 	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		if h, ok := commandHandlers[i.ApplicationCommandData().Name]; ok {
-			h(s, i)
+		switch i.Type {
+		case discordgo.InteractionApplicationCommand:
+			if h, ok := commandHandlers[i.ApplicationCommandData().Name]; ok {
+				h(s, i)
+			}
+		case discordgo.InteractionApplicationCommandAutocomplete:
+			handleAutocomplete(s, i)
+		case discordgo.InteractionMessageComponent:
+			// Custom IDs are "<handler>:<arg>:<arg>..."
+			args := strings.Split(i.MessageComponentData().CustomID, ":")
+			if h, ok := componentHandlers[args[0]]; ok {
+				h(s, i, args[1:])
+			}
 		}
 	})
 }
@@ -170,39 +897,112 @@ func hasManageGuildPermission(i *discordgo.InteractionCreate) bool {
 	return false
 }
 
+// interactionUser returns the user that triggered the interaction, in a guild or a DM
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// respondEphemeral replies to the interaction with a message only the invoking user can see
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	if err := interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Content: content,
+		},
+	}); err != nil {
+		log.Printf("failed to respond: %v", err)
+	}
+}
+
+// optionsByName indexes interaction options by name
+func optionsByName(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	byName := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		byName[opt.Name] = opt
+	}
+	return byName
+}
+
+// Discord rejects message content longer than this
+const maxMessageLength = 2000
+
+// truncateMessage shortens content to fit in a single Discord message. It cuts at a line break when it can,
+// never inside a character, and closes a code block left open so the rest of the table still renders.
+func truncateMessage(content string) string {
+	if len(content) <= maxMessageLength {
+		return content
+	}
+	const fence, suffix = "\n```", "\n…"
+	cut := maxMessageLength - len(fence) - len(suffix)
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	truncated := content[:cut]
+	if n := strings.LastIndexByte(truncated, '\n'); n > cut/2 {
+		truncated = truncated[:n]
+	}
+	if strings.Count(truncated, "```")%2 == 1 {
+		truncated += fence
+	}
+	return truncated + suffix
+}
+
 // UPDATE the BOT Presence Value
 func UpdatePresence(s *discordgo.Session, discordMessage string, marker string) error {
 	activity := "Watching"
 
-	if updateChannelID != "" {
+	if config.Discord.UpdateChannelID != "" {
 		msg := fmt.Sprintf("%s", discordMessage)
-		if messageID, err := getStatusMessageID(s, updateChannelID, marker); err == nil && messageID != "" {
-			editStatusMessage(s, updateChannelID, messageID, msg)
+		if messageID, err := getStatusMessageID(s, config.Discord.UpdateChannelID, marker); err == nil && messageID != "" {
+			editStatusMessage(s, config.Discord.UpdateChannelID, messageID, msg)
 		} else {
-			postMessage(s, updateChannelID, msg)
+			postMessage(s, config.Discord.UpdateChannelID, msg)
 		}
 		msg = ""
 	}
-	return s.UpdateGameStatus(0, fmt.Sprintf("%s: %s", gameName, activity))
+	status := fmt.Sprintf("%s: %s", config.FACEIT.GameID, activity)
+	if dryRun {
+		printDryRun("presence", &discordgo.MessageSend{Content: status})
+		return nil
+	}
+	return s.UpdateGameStatus(0, status)
 }
 
 func UpdateMessage(s *discordgo.Session, discordMessage string, marker string) {
-	if updateChannelID != "" {
+	updateChannelMessage(s, config.Discord.UpdateChannelID, discordMessage, marker)
+}
+
+// updateChannelMessage edits the bot's message containing marker in channelID, or posts it if there is none
+func updateChannelMessage(s *discordgo.Session, channelID string, discordMessage string, marker string) {
+	if channelID != "" {
 		msg := fmt.Sprintf("%s", discordMessage)
-		if messageID, err := getStatusMessageID(s, updateChannelID, marker); err == nil && messageID != "" {
-			editStatusMessage(s, updateChannelID, messageID, msg)
+		if messageID, err := getStatusMessageID(s, channelID, marker); err == nil && messageID != "" {
+			editStatusMessage(s, channelID, messageID, msg)
 		} else {
-			postMessage(s, updateChannelID, msg)
+			postMessage(s, channelID, msg)
 		}
 		msg = ""
 	}
 }
 
 // Post Message to Discord
-// Example: postMessage(s, updateChannelID, msg)
+// Example: postMessage(s, config.Discord.UpdateChannelID, msg)
 // Needs: s *discordgo.Session, channelID string, message string
 func postMessage(s *discordgo.Session, channelID string, message string) error {
 	// log.Printf("Posting message to discord channel ID %s: \n  - %s", channelID, message)
+	return sendMessage(s, channelID, &discordgo.MessageSend{Content: message})
+}
+
+// sendMessage posts a message with embeds or components to a text channel or thread
+func sendMessage(s *discordgo.Session, channelID string, msg *discordgo.MessageSend) error {
+	if dryRun {
+		printDryRun("post to channel "+channelID, msg)
+		return nil
+	}
 	ch, err := s.Channel(channelID)
 	if err != nil {
 		log.Println("Error fetching channel", channelID, err)
@@ -215,7 +1015,7 @@ func postMessage(s *discordgo.Session, channelID string, message string) error {
 	default:
 		return fmt.Errorf("unsupported channel type %d for sending messages; use a text channel or thread", ch.Type)
 	}
-	_, err = s.ChannelMessageSend(channelID, message)
+	_, err = s.ChannelMessageSendComplex(channelID, msg)
 	if err != nil {
 		log.Println("Error posting message to discord channel", err)
 	}
@@ -237,6 +1037,10 @@ func getStatusMessageID(s *discordgo.Session, channelID string, marker string) (
 
 func editStatusMessage(s *discordgo.Session, channelID string, messageID string, message string) error {
 	// log.Println("Editing message in discord channel", channelID, message)
+	if dryRun {
+		printDryRun("edit message "+messageID+" in channel "+channelID, &discordgo.MessageSend{Content: message})
+		return nil
+	}
 	_, err := s.ChannelMessageEdit(channelID, messageID, message)
 	if err != nil {
 		log.Println("Error editing message in discord channel", err)
@@ -250,13 +1054,37 @@ func PostUsageMessage(s *discordgo.Session) {
 ` + "`/list-players`" + ` to list all players currently being tracked
 ` + "`/add-player`" + ` to add a player to the list of players being tracked
 ` + "`/remove-player`" + ` to remove a player from the list of players being tracked
+` + "`/link`" + ` to link your Discord account to your FACEIT account
+` + "`/unlink`" + ` to remove your linked FACEIT account
+` + "`/roles`" + ` to configure roles assigned from FACEIT level/ELO
+` + "`/roster`" + ` to bulk import or export the tracked players
+` + "`/group`" + ` to manage player groups with their own summary tables
+` + "`/filter`" + ` to choose which matches count towards reports
+` + "`/league`" + ` to configure the league/championship report
+` + "`/hub`" + ` to show the FACEIT hub leaderboard and recent matches
+` + "`/rank`" + ` to show regional and country rankings
+` + "`/bans`" + ` to configure FACEIT ban alerts for tracked players
+` + "`/match`" + ` to show the full scoreboard of a match
+` + "`/history`" + ` to page through a player's recent matches
+` + "`/awards`" + ` to configure the weekly awards
+` + "`/profile`" + ` to show a player's profile, recent form and streaks
+` + "`/streak-alerts`" + ` to announce win streaks of tracked players
+` + "`/trend`" + ` to chart ELO, K/D, ADR or HS% over time
+` + "`/sort`" + ` to choose how the summary tables are ordered
+` + "`/deltas`" + ` to show week-over-week changes in the current-week table
+` + "`/export`" + ` to download a report as CSV, JSON or Excel
 	`
 	// UpdatePresence(s, marker+content+"\n ---- \n", marker)
 	UpdateMessage(s, marker+content+"\n ---- \n", marker)
 }
 
+// BotInit registers the slash commands and posts the usage message; call Configure first
 func BotInit(s *discordgo.Session) {
-	loadEnv(true)
-	RegisterSlashCommands(s)
+	migrateLegacyGroups(reportGuildID(s))
+	if dryRun {
+		log.Println("Dry run: slash commands not registered")
+	} else {
+		RegisterSlashCommands(s)
+	}
 	PostUsageMessage(s)
 }
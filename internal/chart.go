@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"time"
+)
+
+// One point of a chart series
+type chartPoint struct {
+	T time.Time
+	V float64
+}
+
+// A line on a chart; the caption names it by its palette color
+type chartSeries struct {
+	Points []chartPoint
+}
+
+// Line colors in the order series are drawn, with the names used in the chart caption
+var chartPalette = []struct {
+	Name  string
+	Color color.RGBA
+}{
+	{"orange", color.RGBA{0xFF, 0x55, 0x00, 0xFF}},
+	{"blue", color.RGBA{0x3B, 0x82, 0xF6, 0xFF}},
+	{"green", color.RGBA{0x22, 0xC5, 0x5E, 0xFF}},
+	{"pink", color.RGBA{0xEC, 0x48, 0x99, 0xFF}},
+	{"yellow", color.RGBA{0xEA, 0xB3, 0x08, 0xFF}},
+}
+
+var (
+	chartBackground = color.RGBA{0x2B, 0x2D, 0x31, 0xFF} // Discord dark theme
+	chartGrid       = color.RGBA{0x4E, 0x50, 0x58, 0xFF}
+	chartText       = color.RGBA{0xDB, 0xDE, 0xE1, 0xFF}
+)
+
+const (
+	chartWidth        = 800
+	chartHeight       = 400
+	chartMarginLeft   = 60
+	chartMarginRight  = 20
+	chartMarginTop    = 20
+	chartMarginBottom = 30
+	chartGridLines    = 5
+	chartFontScale    = 2
+)
+
+// 3x5 glyphs for the axis labels, one row per string, '#' is a lit pixel. Only the characters that appear
+// in numbers and dates are needed.
+var chartGlyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", "..#", "..#"},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'.': {"...", "...", "...", "...", ".#."},
+	'-': {"...", "...", "###", "...", "..."},
+	'/': {"..#", "..#", ".#.", "#..", "#.."},
+}
+
+// drawText draws s with its top-left corner at (x, y) and returns the width drawn
+func drawText(img *image.RGBA, x, y int, s string, c color.Color) int {
+	start := x
+	for _, r := range s {
+		glyph, ok := chartGlyphs[r]
+		if ok {
+			for row, line := range glyph {
+				for col, px := range line {
+					if px == '#' {
+						draw.Draw(img, image.Rect(x+col*chartFontScale, y+row*chartFontScale, x+(col+1)*chartFontScale, y+(row+1)*chartFontScale), image.NewUniform(c), image.Point{}, draw.Src)
+					}
+				}
+			}
+		}
+		x += 4 * chartFontScale
+	}
+	return x - start
+}
+
+func textWidth(s string) int {
+	return len([]rune(s)) * 4 * chartFontScale
+}
+
+// drawLine draws a line of the given thickness with Bresenham's algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1, thickness int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		for tx := 0; tx < thickness; tx++ {
+			for ty := 0; ty < thickness; ty++ {
+				img.SetRGBA(x0+tx-thickness/2, y0+ty-thickness/2, c)
+			}
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x0 += sx
+		} else {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// formatAxisValue keeps the y labels short: whole numbers for large values, two decimals for ratios
+func formatAxisValue(v float64) string {
+	if math.Abs(v) >= 100 {
+		return strconv.Itoa(int(math.Round(v)))
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// renderLineChart draws the series over a shared time axis and returns the PNG
+func renderLineChart(series []chartSeries) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(chartBackground), image.Point{}, draw.Src)
+
+	// Axis ranges over every point
+	first := true
+	var minT, maxT time.Time
+	var minV, maxV float64
+	for _, s := range series {
+		for _, p := range s.Points {
+			if first || p.T.Before(minT) {
+				minT = p.T
+			}
+			if first || p.T.After(maxT) {
+				maxT = p.T
+			}
+			if first || p.V < minV {
+				minV = p.V
+			}
+			if first || p.V > maxV {
+				maxV = p.V
+			}
+			first = false
+		}
+	}
+	if maxV == minV {
+		minV, maxV = minV-1, maxV+1
+	}
+	pad := (maxV - minV) * 0.05
+	minV, maxV = minV-pad, maxV+pad
+	if !maxT.After(minT) {
+		maxT = minT.Add(time.Hour)
+	}
+
+	left, right := chartMarginLeft, chartWidth-chartMarginRight
+	top, bottom := chartMarginTop, chartHeight-chartMarginBottom
+	xOf := func(t time.Time) int {
+		return left + int(float64(right-left)*float64(t.Sub(minT))/float64(maxT.Sub(minT)))
+	}
+	yOf := func(v float64) int {
+		return bottom - int(float64(bottom-top)*(v-minV)/(maxV-minV))
+	}
+
+	// Horizontal grid with value labels
+	for n := 0; n <= chartGridLines; n++ {
+		v := minV + (maxV-minV)*float64(n)/chartGridLines
+		y := yOf(v)
+		drawLine(img, left, y, right, y, 1, chartGrid)
+		label := formatAxisValue(v)
+		drawText(img, left-textWidth(label)-6, y-5*chartFontScale/2, label, chartText)
+	}
+	// Date labels at both ends and the middle
+	loc := reportLocation()
+	for _, t := range []time.Time{minT, minT.Add(maxT.Sub(minT) / 2), maxT} {
+		label := t.In(loc).Format("01/02")
+		x := xOf(t) - textWidth(label)/2
+		if x+textWidth(label) > chartWidth {
+			x = chartWidth - textWidth(label)
+		}
+		drawText(img, x, bottom+8, label, chartText)
+	}
+
+	for n, s := range series {
+		c := chartPalette[n%len(chartPalette)].Color
+		for i, p := range s.Points {
+			x, y := xOf(p.T), yOf(p.V)
+			if i > 0 {
+				drawLine(img, xOf(s.Points[i-1].T), yOf(s.Points[i-1].V), x, y, 2, c)
+			}
+			draw.Draw(img, image.Rect(x-2, y-2, x+3, y+3), image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
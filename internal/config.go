@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is every setting of the bot and the command-line tools. LoadConfig reads it once from a YAML file
+// and the environment; Configure installs it. See config.example.yaml for the file layout.
+type Config struct {
+	Discord   DiscordConfig   `yaml:"discord"`
+	FACEIT    FACEITConfig    `yaml:"faceit"`
+	TimeZone  string          `yaml:"time_zone"` // default US/Eastern
+	TeamName  string          `yaml:"team_name"` // optional: the league team whose matches the summaries leave out
+	Period    PeriodConfig    `yaml:"period"`
+	API       APIConfig       `yaml:"api"`
+	Dashboard DashboardConfig `yaml:"dashboard"`
+
+	// Resolved by Validate
+	location *time.Location
+	period   reportPeriod
+}
+
+type DiscordConfig struct {
+	Token           string `yaml:"token"`
+	AppID           string `yaml:"app_id"`            // needed to register slash commands
+	GuildID         string `yaml:"guild_id"`          // optional: register per-guild for instant availability
+	UpdateChannelID string `yaml:"update_channel_id"` // channel the summaries are posted in
+}
+
+type FACEITConfig struct {
+	GameID string `yaml:"game_id"`
+	AppID  string `yaml:"app_id"`
+	APIKey string `yaml:"api_key"`
+}
+
+// The reporting period, see reportPeriod
+type PeriodConfig struct {
+	StartDay  string `yaml:"start_day"`  // weekday, default monday
+	StartHour int    `yaml:"start_hour"` // 0-23
+	Mode      string `yaml:"mode"`       // weekly (default), biweekly or custom
+	Days      int    `yaml:"days"`       // length of a custom period
+	Anchor    string `yaml:"anchor"`     // YYYY-MM-DD date a period starts on
+}
+
+type APIConfig struct {
+	Addr        string   `yaml:"addr"` // the HTTP API is off when empty
+	Key         string   `yaml:"key"`
+	CORSOrigins []string `yaml:"cors_origins"`
+}
+
+type DashboardConfig struct {
+	Dir   string `yaml:"dir"`   // regenerated every hourly refresh when set
+	Weeks int    `yaml:"weeks"` // default 8
+}
+
+const defaultConfigFile = "config.yaml"
+
+// The environment variables that override the file, with the field each one sets. Empty variables are
+// ignored so a blank line in .env doesn't clear a value from the file.
+func (cfg *Config) envOverrides() []struct {
+	name string
+	set  func(value string) error
+} {
+	str := func(target *string) func(string) error {
+		return func(value string) error { *target = value; return nil }
+	}
+	num := func(target *int) func(string) error {
+		return func(value string) error {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("not a number: %q", value)
+			}
+			*target = n
+			return nil
+		}
+	}
+	list := func(target *[]string) func(string) error {
+		return func(value string) error {
+			*target = nil
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*target = append(*target, item)
+				}
+			}
+			return nil
+		}
+	}
+	return []struct {
+		name string
+		set  func(value string) error
+	}{
+		{"DISCORD_BOT_TOKEN", str(&cfg.Discord.Token)},
+		{"DISCORD_APP_ID", str(&cfg.Discord.AppID)},
+		{"DISCORD_GUILD_ID", str(&cfg.Discord.GuildID)},
+		{"DISCORD_UPDATE_CHANNEL_ID", str(&cfg.Discord.UpdateChannelID)},
+		{"FACEIT_GAME_ID", str(&cfg.FACEIT.GameID)},
+		{"FACEIT_APP_ID", str(&cfg.FACEIT.AppID)},
+		{"FACEIT_API_KEY", str(&cfg.FACEIT.APIKey)},
+		{"TIME_ZONE", str(&cfg.TimeZone)},
+		{"TEAM_NAME", str(&cfg.TeamName)},
+		{"WEEK_START_DAY", str(&cfg.Period.StartDay)},
+		{"WEEK_START_HOUR", num(&cfg.Period.StartHour)},
+		{"REPORT_PERIOD", str(&cfg.Period.Mode)},
+		{"REPORT_PERIOD_DAYS", num(&cfg.Period.Days)},
+		{"REPORT_PERIOD_ANCHOR", str(&cfg.Period.Anchor)},
+		{"API_ADDR", str(&cfg.API.Addr)},
+		{"API_KEY", str(&cfg.API.Key)},
+		{"API_CORS_ORIGINS", list(&cfg.API.CORSOrigins)},
+		{"DASHBOARD_DIR", str(&cfg.Dashboard.Dir)},
+		{"DASHBOARD_WEEKS", num(&cfg.Dashboard.Weeks)},
+	}
+}
+
+// LoadConfig reads the YAML file at path, then applies the environment (including ./.env) on top. An empty
+// path means CONFIG_FILE, or config.yaml when it exists. Unknown keys in the file are an error.
+func LoadConfig(path string) (*Config, error) {
+	godotenv.Load("./.env")
+	cfg := &Config{}
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// Environment only
+	case err != nil:
+		return nil, err
+	default:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		log.Println("Config loaded from", path)
+	}
+
+	var errs []error
+	for _, env := range cfg.envOverrides() {
+		if value := os.Getenv(env.name); value != "" {
+			if err := env.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", env.name, err))
+			}
+		}
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// Validate checks the settings and resolves the time zone and reporting period. The Discord settings are
+// only required for the bot (requireDiscord), not for the command-line tools.
+func (cfg *Config) Validate(requireDiscord bool) error {
+	var errs []error
+	required := func(value, name string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", name))
+		}
+	}
+	if requireDiscord {
+		required(cfg.Discord.Token, "discord.token (DISCORD_BOT_TOKEN)")
+		required(cfg.Discord.AppID, "discord.app_id (DISCORD_APP_ID)")
+	}
+	required(cfg.FACEIT.APIKey, "faceit.api_key (FACEIT_API_KEY)")
+
+	if cfg.TimeZone == "" {
+		cfg.TimeZone = "US/Eastern"
+	}
+	loc, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("time_zone (TIME_ZONE): %w", err))
+	}
+	cfg.location = loc
+
+	period, err := parseReportPeriod(cfg.Period)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.period = period
+
+	if cfg.API.Addr != "" && cfg.API.Key == "" {
+		errs = append(errs, errors.New("api.key (API_KEY) is required when api.addr (API_ADDR) is set"))
+	}
+	switch {
+	case cfg.Dashboard.Weeks < 0:
+		errs = append(errs, errors.New("dashboard.weeks (DASHBOARD_WEEKS) must be positive"))
+	case cfg.Dashboard.Weeks == 0:
+		cfg.Dashboard.Weeks = defaultDashboardWeeks
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateChannels checks that the configured channels exist and take messages. It needs an open session,
+// so it runs after Validate once the bot has connected.
+func (cfg *Config) ValidateChannels(s *discordgo.Session) error {
+	if cfg.Discord.UpdateChannelID == "" {
+		return nil
+	}
+	ch, err := s.Channel(cfg.Discord.UpdateChannelID)
+	if err != nil {
+		return fmt.Errorf("discord.update_channel_id (DISCORD_UPDATE_CHANNEL_ID) %s: %w", cfg.Discord.UpdateChannelID, err)
+	}
+	switch ch.Type {
+	case discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews,
+		discordgo.ChannelTypeGuildPublicThread, discordgo.ChannelTypeGuildPrivateThread, discordgo.ChannelTypeGuildNewsThread:
+		return nil
+	}
+	return fmt.Errorf("discord.update_channel_id (DISCORD_UPDATE_CHANNEL_ID) %s is not a text channel or thread", ch.ID)
+}
+
+// The installed configuration and the FACEIT client built from it. The slash command handlers and the FACEIT
+// helpers are plain package functions, so they read these instead of taking them as parameters; Configure
+// replaces both once at startup and nothing changes them afterwards.
+var (
+	config    = defaultConfig()
+	faceitAPI = newFACEITClient("")
+)
+
+// defaultConfig is the configuration in effect before Configure: an empty config with the defaults Validate
+// fills in, so early code uses the same time zone and period as the bot
+func defaultConfig() *Config {
+	cfg := &Config{}
+	cfg.Validate(false) // only the missing API key is an error, the defaults are set regardless
+	if cfg.location == nil {
+		cfg.location = time.UTC // no time zone database
+	}
+	return cfg
+}
+
+// A FACEIT Data API client
+type faceitClient struct {
+	apiKey string
+	http   *http.Client
+}
+
+func newFACEITClient(apiKey string) *faceitClient {
+	return &faceitClient{apiKey: apiKey, http: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Configure installs a validated configuration; call it once at startup, before opening the Discord session
+// or anything else in the package
+func Configure(cfg *Config) {
+	config = cfg
+	faceitAPI = newFACEITClient(cfg.FACEIT.APIKey)
+	log.Println("Time zone:", cfg.location)
+}
@@ -0,0 +1,372 @@
+package internal
+
+import (
+	"embed"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The static stats site, rendered from the same aggregation as the Discord summaries:
+//
+//	index.html            roster overview and the list of archived weeks
+//	players/<name>.html   one page per tracked player with recent matches and map stats
+//	maps.html             map stats over the archived weeks
+//	weeks/<date>.html     one page per week, named after its first day
+//
+// dashboard.dir (DASHBOARD_DIR) is the output directory; the hourly refresh regenerates the site when it is
+// set. dashboard.weeks (DASHBOARD_WEEKS) is the number of weeks kept in the archive (default 8).
+const (
+	defaultDashboardWeeks = 8
+	dashboardRecentCount  = 20
+)
+
+//go:embed templates/dashboard/*.html
+var dashboardTemplates embed.FS
+
+// One archived reporting period
+type dashboardWeek struct {
+	Window ReportWindow
+	Slug   string
+	Groups []dashboardGroup
+}
+
+type dashboardGroup struct {
+	Name string // "" for the default group
+	Rows []*MatchHistory
+}
+
+// Totals of the matches played on one map
+type mapStats struct {
+	Map       string
+	Matches   int
+	Wins      int
+	Kills     int
+	Deaths    int
+	Headshots int
+	ADRSum    float64
+}
+
+func (m *mapStats) add(match Stats) {
+	m.Matches++
+	if match.Result == 1 {
+		m.Wins++
+	}
+	m.Kills += match.Kills
+	m.Deaths += match.Deaths
+	m.Headshots += match.Headshots
+	m.ADRSum += match.ADR
+}
+
+func (m *mapStats) WinRate() float64 { return float64(m.Wins) / float64(m.Matches) * 100 }
+func (m *mapStats) ADR() float64     { return m.ADRSum / float64(m.Matches) }
+
+func (m *mapStats) KD() float64 {
+	if m.Deaths == 0 {
+		return float64(m.Kills)
+	}
+	return float64(m.Kills) / float64(m.Deaths)
+}
+
+func (m *mapStats) HS() float64 {
+	if m.Kills == 0 {
+		return 0
+	}
+	return float64(m.Headshots) / float64(m.Kills) * 100
+}
+
+// A labelled summary row on a player's page, nil without a valid filter
+type playerWeek struct {
+	Label string
+	Row   *MatchHistory
+}
+
+type dashboardPlayer struct {
+	Slug    string
+	Player  FACEITPlayers
+	Profile FACEITPlayerProfile // zero when the profile could not be fetched
+	Groups  []string
+	Banned  bool
+	Current *MatchHistory
+	Weeks   []playerWeek // this week and last week
+	Recent  []Stats
+	Maps    []*mapStats
+}
+
+// Data of every page; each page uses the fields it needs
+type dashboardPage struct {
+	Title     string
+	Root      string // relative path back to the site root
+	Generated time.Time
+	Weeks     []dashboardWeek
+	Week      dashboardWeek
+	Players   []*dashboardPlayer
+	Player    *dashboardPlayer
+	Maps      []*mapStats
+}
+
+var dashboardFuncs = template.FuncMap{
+	"f1":     func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) },
+	"f2":     func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) },
+	"streak": formatStreak,
+	"stamp":  func(t time.Time) string { return t.Format("01/02/2006 15:04 MST") },
+	"finished": func(millis int64) string {
+		return time.UnixMilli(millis).In(reportLocation()).Format("01/02 15:04")
+	},
+	"result": func(result int) string {
+		if result == 1 {
+			return "W"
+		}
+		return "L"
+	},
+	"winrate": func(mh *MatchHistory) string {
+		if mh == nil || mh.Total_Matches == 0 {
+			return "-"
+		}
+		return strconv.Itoa(mh.Total_Wins*100/mh.Total_Matches) + "%"
+	},
+	"groupTitle": func(name string) string {
+		if name == "" {
+			return "All players"
+		}
+		return name
+	},
+	"playerSlug": playerSlug,
+	"add":        func(a, b int) int { return a + b },
+	"sub":        func(a, b int) int { return a - b },
+	"faceitURL":  func(url string) string { return strings.ReplaceAll(url, "{lang}", "en") },
+}
+
+// playerSlug is the file name of a player's page
+func playerSlug(nickname string) string {
+	return strings.ToLower(nickname)
+}
+
+func dashboardWeekCount() int {
+	if config.Dashboard.Weeks > 0 {
+		return config.Dashboard.Weeks
+	}
+	return defaultDashboardWeeks
+}
+
+// writeDashboardPage renders a page template inside the layout to dir/name
+func writeDashboardPage(dir, name, page string, data dashboardPage) error {
+	tmpl, err := template.New("layout.html").Funcs(dashboardFuncs).ParseFS(dashboardTemplates,
+		"templates/dashboard/layout.html", "templates/dashboard/"+page)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Render next to the page and rename over it, so a web server never serves a half-written page
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := tmpl.Execute(file, data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// pruneDashboardPages deletes the pages in dir/subdir that this run didn't write, such as removed players
+// and weeks that aged out of the archive
+func pruneDashboardPages(dir, subdir string, written map[string]bool) {
+	entries, err := os.ReadDir(filepath.Join(dir, subdir))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("Error listing dashboard pages:", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		name := filepath.Join(subdir, entry.Name())
+		if entry.IsDir() || filepath.Ext(name) != ".html" || written[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			log.Println("Error removing stale dashboard page:", err)
+		}
+	}
+}
+
+// GenerateDashboard renders the stats site to dir. Each player's matches are fetched once for the whole
+// archive and split into weeks locally; the current week uses the current-week report filter and the
+// finished weeks the last-week one, like the Discord summaries.
+func GenerateDashboard(dir string) error {
+	groups := reportGroups(summaryGuildID())
+	current := CurrentWeekWindow(time.Now())
+	windows := []ReportWindow{current}
+	for len(windows) < dashboardWeekCount() {
+		windows = append(windows, windows[len(windows)-1].Previous())
+	}
+	spanStart := windows[len(windows)-1].StartMillis()
+	log.Printf("Generating dashboard in %s: %d weeks", dir, len(windows))
+
+	// A player can be in several groups
+	var players []FACEITPlayers
+	seen := make(map[string]bool)
+	for _, group := range groups {
+		for _, player := range group.Players {
+			if !seen[player.PlayerID] {
+				seen[player.PlayerID] = true
+				players = append(players, player)
+			}
+		}
+	}
+	matchesByPlayer := make(map[string][]Stats) // key: PlayerID
+	for _, player := range players {
+		matches, clipped, err := getPlayerStatsRange(player.PlayerID, spanStart, current.EndMillis())
+		if err != nil {
+			log.Printf("Failed to get player %s stats: %v", player.PlayerName, err)
+		}
+		if clipped {
+			log.Printf("Only the latest %d matches of %s fit in the dashboard", len(matches), player.PlayerName)
+		}
+		matchesByPlayer[player.PlayerID] = matches
+	}
+
+	// Weekly archive
+	var weeks []dashboardWeek
+	for n, window := range windows {
+		slot := "last-week"
+		if n == 0 {
+			slot = "current-week"
+		}
+		sortKey := reportSortKey(slot, "")
+		week := dashboardWeek{Window: window, Slug: window.Start.Format("2006-01-02")}
+		for _, group := range groups {
+			filter, err := compileFilter(reportFilterExpression(slot, group.Filter))
+			if err != nil {
+				log.Printf("Invalid %s filter%s: %v", slot, group.markerSuffix(), err)
+				continue
+			}
+			rows := summarizeMatchHistory(group.Players, matchesByPlayer, filter, window.StartMillis(), window.EndMillis())
+			sortMatchHistory(rows, sortKey)
+			week.Groups = append(week.Groups, dashboardGroup{Name: group.Name, Rows: rows})
+		}
+		weeks = append(weeks, week)
+	}
+
+	// Players and maps, over the matches the finished weeks' filter accepts
+	mapFilter, err := compileFilter(reportFilterExpression("last-week", ""))
+	if err != nil {
+		log.Println("Invalid last-week filter, map stats include every match:", err)
+		mapFilter = allMatches
+	}
+	groupNames := playerGroupNames(guildGroups(summaryGuildID()))
+	banned := activeBans()
+	allMaps := make(map[string]*mapStats)
+	var pages []*dashboardPlayer
+	for _, player := range players {
+		page := &dashboardPlayer{
+			Slug:   playerSlug(player.PlayerName),
+			Player: player,
+			Groups: groupNames[strings.ToLower(player.PlayerName)],
+		}
+		_, page.Banned = banned[player.PlayerID]
+		if profile, err := getPlayerByID(player.PlayerID); err == nil {
+			page.Profile = profile
+		} else {
+			log.Printf("Error getting profile for %s: %v", player.PlayerName, err)
+		}
+		one := []FACEITPlayers{player}
+		if filter, err := compileFilter(reportFilterExpression("current-week", "")); err == nil {
+			page.Current = summarizeMatchHistory(one, matchesByPlayer, filter, current.StartMillis(), current.EndMillis())[0]
+		}
+		last := current.Previous()
+		page.Weeks = []playerWeek{
+			{"This week", page.Current},
+			{"Last week", summarizeMatchHistory(one, matchesByPlayer, mapFilter, last.StartMillis(), last.EndMillis())[0]},
+		}
+
+		matches := matchesByPlayer[player.PlayerID]
+		page.Recent = matches
+		if len(page.Recent) > dashboardRecentCount {
+			page.Recent = page.Recent[:dashboardRecentCount]
+		}
+		playerMaps := make(map[string]*mapStats)
+		for _, match := range matches {
+			if !mapFilter(match) {
+				continue
+			}
+			for _, stats := range []map[string]*mapStats{playerMaps, allMaps} {
+				if stats[match.Map] == nil {
+					stats[match.Map] = &mapStats{Map: match.Map}
+				}
+				stats[match.Map].add(match)
+			}
+		}
+		page.Maps = sortedMapStats(playerMaps)
+		pages = append(pages, page)
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return strings.ToLower(pages[i].Player.PlayerName) < strings.ToLower(pages[j].Player.PlayerName)
+	})
+
+	base := dashboardPage{Generated: time.Now().In(reportLocation()), Weeks: weeks, Players: pages, Maps: sortedMapStats(allMaps)}
+	index := base
+	index.Title = "Roster"
+	if err := writeDashboardPage(dir, "index.html", "index.html", index); err != nil {
+		return err
+	}
+	maps := base
+	maps.Title = "Maps"
+	if err := writeDashboardPage(dir, "maps.html", "maps.html", maps); err != nil {
+		return err
+	}
+	written := make(map[string]bool)
+	for _, player := range pages {
+		page := base
+		page.Title, page.Root, page.Player = player.Player.PlayerName, "../", player
+		name := filepath.Join("players", player.Slug+".html")
+		if err := writeDashboardPage(dir, name, "player.html", page); err != nil {
+			return err
+		}
+		written[name] = true
+	}
+	for _, week := range weeks {
+		page := base
+		page.Title, page.Root, page.Week = "Week of "+week.Window.HumanStart(), "../", week
+		name := filepath.Join("weeks", week.Slug+".html")
+		if err := writeDashboardPage(dir, name, "week.html", page); err != nil {
+			return err
+		}
+		written[name] = true
+	}
+	pruneDashboardPages(dir, "players", written)
+	pruneDashboardPages(dir, "weeks", written)
+	log.Println("Dashboard written to", dir)
+	return nil
+}
+
+// sortedMapStats orders maps by matches played, then name
+func sortedMapStats(stats map[string]*mapStats) []*mapStats {
+	var sorted []*mapStats
+	for _, m := range stats {
+		sorted = append(sorted, m)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Matches != sorted[j].Matches {
+			return sorted[i].Matches > sorted[j].Matches
+		}
+		return sorted[i].Map < sorted[j].Map
+	})
+	return sorted
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Week-over-week columns of the current-week table, computed against the previous week's row
+var deltaColumns = []struct {
+	Header string
+	Format string
+	Value  func(mh *MatchHistory) float64
+}{
+	{"ΔKD", "%.2f", func(mh *MatchHistory) float64 { return mh.Total_KDRatio }},
+	{"ΔADR", "%.1f", func(mh *MatchHistory) float64 { return mh.Total_ADR }},
+	{"ΔWR%", "%.0f", func(mh *MatchHistory) float64 {
+		return float64(mh.Total_Wins) / float64(mh.Total_Matches) * 100
+	}},
+}
+
+// rowsByPlayer indexes the rows that have matches by player ID
+func rowsByPlayer(rows []*MatchHistory) map[string]*MatchHistory {
+	byPlayer := make(map[string]*MatchHistory)
+	for _, mh := range rows {
+		if mh.Total_Matches > 0 {
+			byPlayer[mh.PlayerID] = mh
+		}
+	}
+	return byPlayer
+}
+
+// ANSI colors for the delta arrows. Discord colors them in an ```ansi block; tablewriter ignores the escape
+// codes when it measures the columns.
+const (
+	deltaUpColor   = "\x1b[32m"
+	deltaDownColor = "\x1b[31m"
+	deltaColorEnd  = "\x1b[0m"
+)
+
+// The note that replaces the delta columns when the table would not fit in one message with them
+const deltasOmittedNote = "\n*Weekly changes left out, the table is too long for one message with them.*"
+
+// formatDelta renders a change with a green or red arrow; rounding to the displayed precision decides "="
+func formatDelta(format string, change float64) string {
+	text := fmt.Sprintf(format, change)
+	zero := fmt.Sprintf(format, 0.0)
+	switch {
+	case text == zero || text == "-"+zero:
+		return "="
+	case change > 0:
+		return deltaUpColor + "▲" + text + deltaColorEnd
+	}
+	return deltaDownColor + "▼" + fmt.Sprintf(format, -change) + deltaColorEnd
+}
+
+// deltaCells renders a row's delta columns, "-" when either week has no matches
+func deltaCells(mh *MatchHistory, previous map[string]*MatchHistory) []string {
+	before, ok := previous[mh.PlayerID]
+	cells := make([]string, 0, len(deltaColumns))
+	for _, column := range deltaColumns {
+		if !ok || mh.Total_Matches == 0 {
+			cells = append(cells, "-")
+			continue
+		}
+		cells = append(cells, formatDelta(column.Format, column.Value(mh)-column.Value(before)))
+	}
+	return cells
+}
+
+// reportDeltasEnabled reports whether the current-week table shows week-over-week deltas
+func reportDeltasEnabled() bool {
+	filterMu.Lock()
+	defer filterMu.Unlock()
+	return loadFilterSettings().Reports["current-week"].Deltas
+}
+
+func handleDeltas(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	enabled := i.ApplicationCommandData().Options[0].BoolValue()
+	if err := updateFilterSettings(func(settings *filterSettings) {
+		cfg := settings.Reports["current-week"]
+		cfg.Deltas = enabled
+		settings.Reports["current-week"] = cfg
+	}); err != nil {
+		log.Println("Error saving report settings", err)
+		respondEphemeral(s, i, "Could not save the setting, try again later.")
+		return
+	}
+	if enabled {
+		respondEphemeral(s, i, "The current-week table shows K/D, ADR and win rate changes against last week from the next refresh.")
+		return
+	}
+	respondEphemeral(s, i, "Week-over-week changes hidden from the next refresh.")
+}
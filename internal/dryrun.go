@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// In dry-run mode every write to Discord is printed to dryRunOut instead: channel messages, message edits,
+// interaction responses and follow-ups, presence and role changes. Reads still go to Discord, so edits are
+// previewed against the real messages. Slash commands are not registered and the data files are left
+// untouched, so a preview doesn't change what the running bot announces next.
+var (
+	dryRun    bool
+	dryRunOut io.Writer = os.Stdout
+	dryRunMu  sync.Mutex
+)
+
+// SetDryRun turns dry-run mode on or off; call it before BotInit
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
+// printDryRun writes one intercepted call with its exact payload
+func printDryRun(action string, msg *discordgo.MessageSend) {
+	dryRunMu.Lock()
+	defer dryRunMu.Unlock()
+	var b strings.Builder
+	fmt.Fprintf(&b, "----- [dry-run] %s -----\n", action)
+	if msg != nil {
+		if msg.Content != "" {
+			b.WriteString(msg.Content)
+			if !strings.HasSuffix(msg.Content, "\n") {
+				b.WriteString("\n")
+			}
+		}
+		for _, embed := range msg.Embeds {
+			data, err := json.MarshalIndent(embed, "", "  ")
+			if err != nil {
+				data = []byte(err.Error())
+			}
+			fmt.Fprintf(&b, "[embed]\n%s\n", data)
+		}
+		for _, file := range msg.Files {
+			size := "unknown size"
+			if sizer, ok := file.Reader.(interface{ Len() int }); ok {
+				size = fmt.Sprintf("%d bytes", sizer.Len())
+			}
+			fmt.Fprintf(&b, "[attachment] %s (%s, %s)\n", file.Name, file.ContentType, size)
+		}
+		if len(msg.Components) > 0 {
+			data, err := json.Marshal(msg.Components)
+			if err != nil {
+				data = []byte(err.Error())
+			}
+			fmt.Fprintf(&b, "[components] %s\n", data)
+		}
+	}
+	io.WriteString(dryRunOut, b.String())
+}
+
+// interactionRespond answers an interaction, or prints the response in dry-run mode
+func interactionRespond(s *discordgo.Session, interaction *discordgo.Interaction, resp *discordgo.InteractionResponse) error {
+	if !dryRun {
+		return s.InteractionRespond(interaction, resp)
+	}
+	action := fmt.Sprintf("respond to %s interaction %s", interaction.Type, interaction.ID)
+	msg := &discordgo.MessageSend{}
+	switch {
+	case resp.Type == discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		resp.Type == discordgo.InteractionResponseDeferredMessageUpdate:
+		action += " (deferred)"
+	case resp.Data == nil:
+	case resp.Type == discordgo.InteractionApplicationCommandAutocompleteResult:
+		var names []string
+		for _, choice := range resp.Data.Choices {
+			names = append(names, choice.Name)
+		}
+		msg.Content = "choices: " + strings.Join(names, ", ")
+	default:
+		msg = &discordgo.MessageSend{
+			Content:    resp.Data.Content,
+			Embeds:     resp.Data.Embeds,
+			Files:      resp.Data.Files,
+			Components: resp.Data.Components,
+		}
+	}
+	printDryRun(action, msg)
+	return nil
+}
+
+// interactionResponseEdit edits an interaction's response, or prints the edit in dry-run mode
+func interactionResponseEdit(s *discordgo.Session, interaction *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	if !dryRun {
+		return s.InteractionResponseEdit(interaction, edit)
+	}
+	msg := &discordgo.MessageSend{Files: edit.Files}
+	if edit.Content != nil {
+		msg.Content = *edit.Content
+	}
+	if edit.Embeds != nil {
+		msg.Embeds = *edit.Embeds
+	}
+	if edit.Components != nil {
+		msg.Components = *edit.Components
+	}
+	printDryRun("edit response to interaction "+interaction.ID, msg)
+	return &discordgo.Message{}, nil
+}
+
+// followupMessageCreate sends a follow-up message to an interaction, or prints it in dry-run mode
+func followupMessageCreate(s *discordgo.Session, interaction *discordgo.Interaction, wait bool, params *discordgo.WebhookParams) (*discordgo.Message, error) {
+	if !dryRun {
+		return s.FollowupMessageCreate(interaction, wait, params)
+	}
+	printDryRun("follow up interaction "+interaction.ID, &discordgo.MessageSend{
+		Content:    params.Content,
+		Embeds:     params.Embeds,
+		Files:      params.Files,
+		Components: params.Components,
+	})
+	return &discordgo.Message{}, nil
+}
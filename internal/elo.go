@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A point-in-time reading of a player's CS2 ELO and skill level
+type ELOSnapshot struct {
+	PlayerID   string `json:"player_id"`
+	Nickname   string `json:"nickname"`
+	Elo        int    `json:"elo"`
+	SkillLevel int    `json:"skill_level"`
+	TakenAt    int64  `json:"taken_at"`
+}
+
+// This struct is the layout of data/elo_snapshots.json
+type eloSnapshots struct {
+	Snapshots []ELOSnapshot `json:"snapshots"`
+}
+
+const (
+	eloSnapshotsFile = "elo_snapshots.json"
+	// An unchanged ELO is still recorded once a day so the time series has no long gaps
+	eloSnapshotMaxAge = 24 * time.Hour
+)
+
+var eloMu sync.Mutex
+
+func loadELOSnapshots() eloSnapshots {
+	snapshots := eloSnapshots{Snapshots: []ELOSnapshot{}}
+	if err := readDataFile(eloSnapshotsFile, &snapshots); err != nil {
+		log.Println("Error reading", eloSnapshotsFile, err)
+	}
+	return snapshots
+}
+
+// latestELOSnapshots returns the newest snapshot per player ID
+func latestELOSnapshots() map[string]ELOSnapshot {
+	eloMu.Lock()
+	defer eloMu.Unlock()
+	latest := make(map[string]ELOSnapshot)
+	for _, snap := range loadELOSnapshots().Snapshots {
+		if prev, ok := latest[snap.PlayerID]; !ok || snap.TakenAt >= prev.TakenAt {
+			latest[snap.PlayerID] = snap
+		}
+	}
+	return latest
+}
+
+// snapshotPlayers returns the tracked roster plus every verified linked account, de-duplicated by player ID
+func snapshotPlayers() []FACEITPlayers {
+	seen := make(map[string]bool)
+	var players []FACEITPlayers
+	for _, player := range getPlayerIDs() {
+		seen[player.PlayerID] = true
+		players = append(players, player)
+	}
+	for _, link := range getVerifiedLinks() {
+		if !seen[link.PlayerID] {
+			seen[link.PlayerID] = true
+			players = append(players, FACEITPlayers{PlayerName: link.Nickname, PlayerID: link.PlayerID})
+		}
+	}
+	return players
+}
+
+// TakeELOSnapshots records the current CS2 ELO of every tracked and linked player and returns the latest
+// snapshot per player ID
+func TakeELOSnapshots() map[string]ELOSnapshot {
+	now := time.Now()
+	var fresh []ELOSnapshot
+	for _, player := range snapshotPlayers() {
+		profile, err := getPlayerByID(player.PlayerID)
+		if err != nil {
+			log.Printf("Error getting profile for %s: %v ... Continuing", player.PlayerName, err)
+			continue
+		}
+		game, ok := profile.Games["cs2"]
+		if !ok {
+			continue
+		}
+		fresh = append(fresh, ELOSnapshot{
+			PlayerID:   player.PlayerID,
+			Nickname:   profile.Nickname,
+			Elo:        game.FaceitElo,
+			SkillLevel: game.SkillLevel,
+			TakenAt:    now.Unix(),
+		})
+	}
+
+	latest := latestELOSnapshots()
+	eloMu.Lock()
+	defer eloMu.Unlock()
+	snapshots := loadELOSnapshots()
+	changed := false
+	for _, snap := range fresh {
+		prev, ok := latest[snap.PlayerID]
+		if ok && prev.Elo == snap.Elo && prev.SkillLevel == snap.SkillLevel &&
+			now.Sub(time.Unix(prev.TakenAt, 0)) < eloSnapshotMaxAge {
+			continue
+		}
+		snapshots.Snapshots = append(snapshots.Snapshots, snap)
+		latest[snap.PlayerID] = snap
+		changed = true
+	}
+	if changed {
+		if err := writeDataFile(eloSnapshotsFile, snapshots); err != nil {
+			log.Println("Error writing", eloSnapshotsFile, err)
+		}
+	}
+	log.Println("ELO snapshots taken for", len(fresh), "players")
+	return latest
+}
+
+// eloChanges returns each player's ELO change over [start, end), measured from the last snapshot before the
+// window (or the first one inside it) to the last snapshot inside it
+func eloChanges(start, end time.Time) map[string]int {
+	eloMu.Lock()
+	defer eloMu.Unlock()
+	before := make(map[string]ELOSnapshot)   // newest snapshot before start
+	earliest := make(map[string]ELOSnapshot) // oldest snapshot inside the window
+	latest := make(map[string]ELOSnapshot)   // newest snapshot inside the window
+	for _, snap := range loadELOSnapshots().Snapshots {
+		taken := time.Unix(snap.TakenAt, 0)
+		switch {
+		case taken.Before(start):
+			if prev, ok := before[snap.PlayerID]; !ok || snap.TakenAt > prev.TakenAt {
+				before[snap.PlayerID] = snap
+			}
+		case taken.Before(end):
+			if prev, ok := earliest[snap.PlayerID]; !ok || snap.TakenAt < prev.TakenAt {
+				earliest[snap.PlayerID] = snap
+			}
+			if prev, ok := latest[snap.PlayerID]; !ok || snap.TakenAt > prev.TakenAt {
+				latest[snap.PlayerID] = snap
+			}
+		}
+	}
+	changes := make(map[string]int)
+	for playerID, to := range latest {
+		from, ok := before[playerID]
+		if !ok {
+			from = earliest[playerID]
+		}
+		changes[playerID] = to.Elo - from.Elo
+	}
+	return changes
+}
+
+// eloHistory returns a player's snapshots taken since the given time, oldest first
+func eloHistory(playerID string, since time.Time) []ELOSnapshot {
+	eloMu.Lock()
+	defer eloMu.Unlock()
+	var history []ELOSnapshot
+	for _, snap := range loadELOSnapshots().Snapshots {
+		if snap.PlayerID == playerID && snap.TakenAt >= since.Unix() {
+			history = append(history, snap)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].TakenAt < history[j].TakenAt })
+	return history
+}
@@ -0,0 +1,229 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A sheet of an export: CSV file, JSON array or XLSX worksheet. Cells are strings, ints, float64s or bools.
+type exportTable struct {
+	Name   string
+	Header []string
+	Rows   [][]interface{}
+}
+
+// An export file, either attached in Discord or written to disk by cmd/export
+type ExportFile struct {
+	Name string
+	Data []byte
+}
+
+var exportFormats = map[string]string{
+	"csv":  "text/csv",
+	"json": "application/json",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+var exportSummaryHeader = []string{
+	"group", "position", "player_id", "nickname", "matches", "wins", "losses", "win_rate", "kd_ratio",
+	"hs_percentage", "adr", "kills", "mvps", "quadro_kills", "penta_kills", "current_streak",
+	"longest_win_streak", "longest_loss_streak", "elo_change", "banned",
+}
+
+var exportMatchHeader = []string{
+	"group", "player_id", "nickname", "match_id", "finished_at", "map", "team", "result", "score", "kills",
+	"deaths", "assists", "kd_ratio", "adr", "hs_percentage", "mvps", "triple_kills", "quadro_kills", "penta_kills",
+}
+
+// buildExportTables aggregates every group for the window like the summaries do, with each player's
+// matches fetched once. The matches table, when requested, holds the matches each group's filter counted.
+func buildExportTables(window apiWindow, slot string, withMatches bool) []exportTable {
+	groups := reportGroups(summaryGuildID())
+	start, end := ToUnixMillis(window.Start), ToUnixMillis(window.End)
+	matchesByPlayer := make(map[string][]Stats) // key: PlayerID
+	for _, group := range groups {
+		for _, player := range group.Players {
+			if _, ok := matchesByPlayer[player.PlayerID]; ok {
+				continue
+			}
+			matches, err := getPlayerStats(player.PlayerID, start, end)
+			if err != nil {
+				log.Printf("Failed to get player %s stats: %v", player.PlayerName, err)
+			}
+			matchesByPlayer[player.PlayerID] = matches
+		}
+	}
+
+	summary := exportTable{Name: "Summary", Header: exportSummaryHeader}
+	matchRows := exportTable{Name: "Matches", Header: exportMatchHeader}
+	sortKey := reportSortKey(slot, "")
+	banned := activeBans()
+	loc := reportLocation()
+	for _, group := range groups {
+		filter, err := compileFilter(reportFilterExpression(slot, group.Filter))
+		if err != nil {
+			log.Printf("Invalid %s filter%s: %v", slot, group.markerSuffix(), err)
+			continue
+		}
+		rows := summarizeMatchHistory(group.Players, matchesByPlayer, filter, start, end)
+		sortMatchHistory(rows, sortKey)
+		for n, mh := range rows {
+			r := toAPIRow(mh, n+1, banned)
+			summary.Rows = append(summary.Rows, []interface{}{
+				group.Name, r.Position, r.PlayerID, r.Nickname, r.Matches, r.Wins, r.Losses, r.WinRate, r.KDRatio,
+				r.HSPercentage, r.ADR, r.Kills, r.MVPs, r.QuadroKills, r.PentaKills, r.CurrentStreak,
+				r.LongestWinStreak, r.LongestLossStreak, r.ELOChange, r.Banned,
+			})
+		}
+		if !withMatches {
+			continue
+		}
+		for _, player := range group.Players {
+			for _, s := range matchesByPlayer[player.PlayerID] {
+				if s.MatchFinishedAt < start || s.MatchFinishedAt >= end || !filter(s) {
+					continue
+				}
+				result := "loss"
+				if s.Result == 1 {
+					result = "win"
+				}
+				matchRows.Rows = append(matchRows.Rows, []interface{}{
+					group.Name, player.PlayerID, player.PlayerName, s.MatchID,
+					time.UnixMilli(s.MatchFinishedAt).In(loc).Format(time.RFC3339), s.Map, s.Team, result, s.Score,
+					s.Kills, s.Deaths, s.Assists, s.KDRatio, s.ADR, s.HeadshotsPercentage, s.MVPs, s.TripleKills,
+					s.QuadroKills, s.PentaKills,
+				})
+			}
+		}
+	}
+	if withMatches {
+		return []exportTable{summary, matchRows}
+	}
+	return []exportTable{summary}
+}
+
+func csvCell(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func writeCSV(table exportTable) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(table.Header)
+	for _, row := range table.Rows {
+		cells := make([]string, len(row))
+		for n, v := range row {
+			cells[n] = csvCell(v)
+		}
+		w.Write(cells)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// exportJSON writes the tables as arrays of objects keyed by the header, under the lower-cased table name
+func exportJSON(window apiWindow, tables []exportTable) ([]byte, error) {
+	out := map[string]interface{}{"window": window}
+	for _, table := range tables {
+		objects := []map[string]interface{}{}
+		for _, row := range table.Rows {
+			object := make(map[string]interface{}, len(row))
+			for n, v := range row {
+				object[table.Header[n]] = v
+			}
+			objects = append(objects, object)
+		}
+		out[strings.ToLower(table.Name)] = objects
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	err := enc.Encode(out)
+	return buf.Bytes(), err
+}
+
+// Export builds the report for a window ("current", "last" or "<n>d") in the given format. CSV gives one
+// file per table; JSON and XLSX hold every table in one file.
+func Export(windowName, format string, withMatches bool) ([]ExportFile, error) {
+	if _, ok := exportFormats[format]; !ok {
+		return nil, fmt.Errorf("unknown format %q, use csv, json or xlsx", format)
+	}
+	window, slot, err := resolveReportWindow(windowName, "last")
+	if err != nil {
+		return nil, err
+	}
+	tables := buildExportTables(window, slot, withMatches)
+	base := "report-" + window.Name + "-" + window.Start.Format("2006-01-02")
+	switch format {
+	case "csv":
+		var files []ExportFile
+		for _, table := range tables {
+			data, err := writeCSV(table)
+			if err != nil {
+				return nil, err
+			}
+			name := base + ".csv"
+			if table.Name != "Summary" {
+				name = base + "-" + strings.ToLower(table.Name) + ".csv"
+			}
+			files = append(files, ExportFile{Name: name, Data: data})
+		}
+		return files, nil
+	case "json":
+		data, err := exportJSON(window, tables)
+		return []ExportFile{{Name: base + ".json", Data: data}}, err
+	}
+	data, err := writeXLSX(tables)
+	return []ExportFile{{Name: base + ".xlsx", Data: data}}, err
+}
+
+func handleExport(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := optionsByName(i.ApplicationCommandData().Options)
+	windowName := opts["window"].StringValue()
+	format := "csv"
+	if opt, ok := opts["format"]; ok {
+		format = opt.StringValue()
+	}
+	withMatches := false
+	if opt, ok := opts["matches"]; ok {
+		withMatches = opt.BoolValue()
+	}
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	go func() {
+		files, err := Export(windowName, format, withMatches)
+		content := "Export ready."
+		edit := &discordgo.WebhookEdit{Content: &content}
+		if err != nil {
+			log.Println("Error building export", err)
+			content = "Could not build the export: " + err.Error()
+		}
+		for _, file := range files {
+			edit.Files = append(edit.Files, &discordgo.File{
+				Name:        file.Name,
+				ContentType: exportFormats[format],
+				Reader:      bytes.NewReader(file.Data),
+			})
+		}
+		if _, err := interactionResponseEdit(s, i.Interaction, edit); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+	}()
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,6 +13,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -20,7 +22,15 @@ import (
 
 // This struct is used to read the player names from the json file
 type FACEITPlayerNames struct {
-	PlayerName []string `json:"players"`
+	PlayerName []string      `json:"players"`
+	Groups     []PlayerGroup `json:"groups,omitempty"` // legacy global groups, moved to the guild settings on startup
+}
+
+// A named subset of the tracked players that gets its own summary tables
+type PlayerGroup struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+	Filter  string   `json:"filter,omitempty"` // match filter expression, see matchFilter
 }
 
 type FACEITPlayerID struct {
@@ -33,12 +43,79 @@ type FACEITPlayers struct {
 	PlayerID   string
 }
 
-// function to query the FACEIT API and return the response
-func QueryFACEITAPI(endpoint string, params map[string]interface{}) *http.Response {
+// Subset of the FACEIT player object (/players and /players/{player_id})
+type FACEITPlayerProfile struct {
+	PlayerID      string                      `json:"player_id"`
+	Nickname      string                      `json:"nickname"`
+	Avatar        string                      `json:"avatar"`
+	Country       string                      `json:"country"`
+	FaceitURL     string                      `json:"faceit_url"`
+	SteamNickname string                      `json:"steam_nickname"`
+	Platforms     map[string]string           `json:"platforms"`
+	Settings      map[string]string           `json:"settings"`
+	Games         map[string]FACEITGameDetail `json:"games"`
+}
+
+type FACEITGameDetail struct {
+	FaceitElo    int    `json:"faceit_elo"`
+	SkillLevel   int    `json:"skill_level"`
+	Region       string `json:"region"`
+	GamePlayerID string `json:"game_player_id"`
+}
+
+// decodeFACEITResponse reads and closes the response body and unmarshals it into v when the status is 200
+func decodeFACEITResponse(response *http.Response, v interface{}) error {
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return &faceitStatusError{Code: response.StatusCode, Status: response.Status}
+	}
+	return json.Unmarshal(body, v)
+}
+
+// A non-200 answer from the FACEIT API
+type faceitStatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *faceitStatusError) Error() string { return "FACEIT API returned " + e.Status }
+
+// transientFACEITError reports whether a FACEIT call may succeed when retried: rate limits, server errors
+// and network failures. Anything else, such as a 404, fails the same way every time.
+func transientFACEITError(err error) bool {
+	var status *faceitStatusError
+	if errors.As(err, &status) {
+		return status.Code == http.StatusTooManyRequests || status.Code >= 500
+	}
+	var transport *url.Error
+	return errors.As(err, &transport)
+}
+
+// getPlayerByNickname resolves a FACEIT nickname to the full player object
+func getPlayerByNickname(nickname string) (FACEITPlayerProfile, error) {
+	var profile FACEITPlayerProfile
+	err := getFACEIT("/players", map[string]interface{}{"nickname": nickname}, &profile)
+	return profile, err
+}
+
+// getPlayerByID fetches the full player object for a FACEIT player ID
+func getPlayerByID(playerID string) (FACEITPlayerProfile, error) {
+	var profile FACEITPlayerProfile
+	err := getFACEIT("/players/"+url.PathEscape(playerID), nil, &profile)
+	return profile, err
+}
+
+// function to query the FACEIT API and return the response. Network failures are returned rather than
+// fatal, so a FACEIT outage only fails the command or refresh that ran into it.
+func QueryFACEITAPI(endpoint string, params map[string]interface{}) (*http.Response, error) {
 	baseURL := "https://open.faceit.com/data/v4"
 	u, err := url.Parse(baseURL + endpoint)
 	if err != nil {
-		log.Fatal("Error parsing URL: ", err)
+		return nil, err
 	}
 
 	q := u.Query()
@@ -55,28 +132,30 @@ func QueryFACEITAPI(endpoint string, params map[string]interface{}) *http.Respon
 		case bool:
 			q.Set(key, strconv.FormatBool(v))
 		default:
-			log.Fatalf("Unsupported type: %T", v)
+			return nil, fmt.Errorf("unsupported type for %s: %T", key, v)
 		}
 	}
 	u.RawQuery = q.Encode()
 	// log.Printf("Querying FACEIT API: %s", u.String())
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		log.Fatal("Error creating request: ", err)
+		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+faceitAPIKey)
+	req.Header.Add("Authorization", "Bearer "+faceitAPI.apiKey)
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("User-Agent", "faceit-integration/1.0 (+https://open.faceit.com)")
 
-	resp, err := client.Do(req)
+	return faceitAPI.http.Do(req)
+}
+
+// getFACEIT queries an endpoint and decodes the JSON answer into v
+func getFACEIT(endpoint string, params map[string]interface{}, v interface{}) error {
+	response, err := QueryFACEITAPI(endpoint, params)
 	if err != nil {
-		log.Fatal("Error querying FACEIT API: ", err)
+		return err
 	}
-	return resp
+	return decodeFACEITResponse(response, v)
 }
 
 // Load the faceit player nicknames from data/faceit_player_ids.json
@@ -95,6 +174,29 @@ func loadPlayerJSON() FACEITPlayerNames {
 	return faceitPlayerNames
 }
 
+// playersMu serializes changes to data/faceit_player_names.json
+var playersMu sync.Mutex
+
+// updatePlayerJSON loads the player file, applies update and saves the file when update returns true, all
+// under playersMu so concurrent changes aren't lost
+func updatePlayerJSON(update func(names *FACEITPlayerNames) bool) {
+	playersMu.Lock()
+	defer playersMu.Unlock()
+	names := loadPlayerJSON()
+	if update(&names) {
+		savePlayerJSON(names)
+	}
+}
+
+func savePlayerJSON(names FACEITPlayerNames) {
+	sort.Slice(names.PlayerName, func(i, j int) bool {
+		return strings.ToLower(names.PlayerName[i]) < strings.ToLower(names.PlayerName[j])
+	})
+	if err := writeDataFile("faceit_player_names.json", names); err != nil {
+		log.Fatal("Error writing faceit_player_names.json: ", err)
+	}
+}
+
 func parseFACEITResponsePlayerNamesToIDs(response *http.Response) FACEITPlayerID {
 	// Read in the body of a response that is returning a players json object
 	body, err := io.ReadAll(response.Body)
@@ -115,7 +217,11 @@ func getPlayerIDs() []FACEITPlayers {
 	players := loadPlayerJSON()
 	var faceitPlayers []FACEITPlayers
 	for _, player := range players.PlayerName {
-		response := QueryFACEITAPI("/players", map[string]interface{}{"nickname": player})
+		response, err := QueryFACEITAPI("/players", map[string]interface{}{"nickname": player})
+		if err != nil {
+			log.Printf("Error getting player name for: %s: %v ... Continuing", player, err)
+			continue
+		}
 		if response.StatusCode != 200 {
 			log.Printf("Error getting player name for: %s ... Continuing", player)
 			continue
@@ -167,7 +273,56 @@ type Stats struct {
 	BestOf              int     `json:"Best Of,string"`
 	QuadroKills         int     `json:"Quadro Kills,string"`
 }
+
+// Subset of the FACEIT match object (/matches/{match_id} and competition match lists)
+type FACEITMatch struct {
+	MatchID         string                   `json:"match_id"`
+	CompetitionID   string                   `json:"competition_id"`
+	CompetitionName string                   `json:"competition_name"`
+	CompetitionType string                   `json:"competition_type"`
+	BestOf          int                      `json:"best_of"`
+	Region          string                   `json:"region"`
+	Status          string                   `json:"status"`
+	StartedAt       int64                    `json:"started_at"`
+	FinishedAt      int64                    `json:"finished_at"`
+	FaceitURL       string                   `json:"faceit_url"`
+	Teams           map[string]FACEITFaction `json:"teams"` // key: faction1, faction2
+	Results         struct {
+		Winner string         `json:"winner"`
+		Score  map[string]int `json:"score"`
+	} `json:"results"`
+	Voting struct {
+		Map struct {
+			Pick []string `json:"pick"`
+		} `json:"map"`
+	} `json:"voting"`
+}
+
+// mapName returns the picked map, or "" before the veto has finished
+func (m FACEITMatch) mapName() string {
+	if len(m.Voting.Map.Pick) == 0 {
+		return ""
+	}
+	return m.Voting.Map.Pick[0]
+}
+
+type FACEITFaction struct {
+	FactionID string `json:"faction_id"`
+	Name      string `json:"name"`
+	Roster    []struct {
+		PlayerID       string `json:"player_id"`
+		Nickname       string `json:"nickname"`
+		GameSkillLevel int    `json:"game_skill_level"`
+	} `json:"roster"`
+}
+
+// Wrapper for FACEIT match lists (championship, hub and tournament matches)
+type matchList struct {
+	Items []FACEITMatch `json:"items"`
+}
+
 type MatchHistory struct {
+	PlayerID            string
 	Nickname            string
 	Team                string
 	Total_Wins          int
@@ -175,6 +330,15 @@ type MatchHistory struct {
 	Total_Matches       int
 	Total_KDRatio       float64
 	Total_HS_Percentage float64
+	Total_Kills         int
+	Total_ADR           float64 // average per match
+	Total_MVPs          int
+	Total_Quadro_Kills  int
+	Total_Penta_Kills   int
+	Longest_Loss_Streak int
+	Longest_Win_Streak  int
+	Current_Streak      int // positive for wins, negative for losses
+	ELO_Change          int // from the ELO snapshots, 0 without snapshots in the window
 }
 
 // Wrapper for the FACEIT stats list response
@@ -186,17 +350,112 @@ type playerStatsList struct {
 	End   int `json:"end"`
 }
 
-func getMatchHistory(start, end int64, human_start, human_end string) string {
-	var discordMessage string
-	discordMessage += "**Match History**: " + human_start + " -> " + human_end + "\n\n"
-	faceitPlayers := getPlayerIDs()
+// The stats endpoint returns at most 100 items per page and rejects offsets above 200
+const (
+	statsPageSize  = 100
+	statsMaxOffset = 200
+	// Matches read per player for a summary window, one request each
+	summaryMatchLimit = 30
+	// Upper bound for the longer ranges, ten requests per player
+	rangeMatchLimit = 1000
+)
+
+// getPlayerStats returns up to summaryMatchLimit of a player's CS2 matches in [start, end) (unix millis),
+// newest first. Weekly summaries read one page per player so the hourly refresh stays cheap; longer windows
+// (bi-weekly and custom periods, /export and API ranges) page through like getPlayerStatsRange.
+func getPlayerStats(playerID string, start, end int64) ([]Stats, error) {
+	if time.Duration(end-start)*time.Millisecond > 7*24*time.Hour+time.Hour {
+		stats, clipped, err := getPlayerStatsRange(playerID, start, end)
+		if clipped {
+			log.Printf("Only the latest %d matches of %s fit in the window", len(stats), playerID)
+		}
+		return stats, err
+	}
 	// Endpoint is /players/{player_id}/games/cs2/stats?from=<INTEGER>&to=<INTEGER>&offset=0&limit=30
+	var list playerStatsList
+	err := getFACEIT("/players/"+playerID+"/games/cs2/stats", map[string]interface{}{
+		"from":   start,
+		"to":     end,
+		"offset": 0,
+		"limit":  summaryMatchLimit,
+	}, &list)
+	stats := make([]Stats, 0, len(list.Items))
+	for _, it := range list.Items {
+		stats = append(stats, it.Stats)
+	}
+	return stats, err
+}
+
+// getPlayerStatsRange returns a player's CS2 matches in [start, end) like getPlayerStats, following the
+// stats endpoint's pagination for the longer ranges of the dashboard and trends. Past the largest offset
+// it continues before the oldest match read so far. clipped is true when rangeMatchLimit was reached
+// before start.
+func getPlayerStatsRange(playerID string, start, end int64) (stats []Stats, clipped bool, err error) {
+	seen := make(map[string]bool) // key: MatchID
+	to := end
+	for len(stats) < rangeMatchLimit {
+		oldest, added, full := to, 0, false
+		for offset := 0; offset <= statsMaxOffset; offset += statsPageSize {
+			var list playerStatsList
+			if err := getFACEIT("/players/"+playerID+"/games/cs2/stats", map[string]interface{}{
+				"from":   start,
+				"to":     to,
+				"offset": offset,
+				"limit":  statsPageSize,
+			}, &list); err != nil {
+				return stats, false, err
+			}
+			for _, it := range list.Items {
+				if seen[it.Stats.MatchID] {
+					continue
+				}
+				seen[it.Stats.MatchID] = true
+				stats = append(stats, it.Stats)
+				added++
+				oldest = min(oldest, it.Stats.MatchFinishedAt)
+			}
+			if full = len(list.Items) == statsPageSize; !full {
+				break
+			}
+		}
+		if !full {
+			return stats, false, nil
+		}
+		if added == 0 || oldest >= to {
+			break
+		}
+		to = oldest
+	}
+	if len(stats) > rangeMatchLimit {
+		stats = stats[:rangeMatchLimit]
+	}
+	return stats, true, nil
+}
+
+// aggregateMatchHistory totals the matches accepted by filter for each player in [start, end). Rows are
+// sorted by Total_Matches desc, then Nickname case-insensitive asc.
+func aggregateMatchHistory(faceitPlayers []FACEITPlayers, filter matchFilter, start, end int64) []*MatchHistory {
 	log.Println("Getting match history for", len(faceitPlayers), "players")
+	matchesByPlayer := make(map[string][]Stats) // key: PlayerID
+	for _, player := range faceitPlayers {
+		matches, err := getPlayerStats(player.PlayerID, start, end)
+		if err != nil {
+			log.Printf("Failed to get player %s stats: %v", player.PlayerName, err)
+		}
+		matchesByPlayer[player.PlayerID] = matches
+	}
+	return summarizeMatchHistory(faceitPlayers, matchesByPlayer, filter, start, end)
+}
 
+// summarizeMatchHistory totals already fetched matches the way aggregateMatchHistory does, counting only
+// matches finished in [start, end). This lets callers fetch a long span once and report several windows.
+func summarizeMatchHistory(faceitPlayers []FACEITPlayers, matchesByPlayer map[string][]Stats, filter matchFilter, start, end int64) []*MatchHistory {
 	type runningTotals struct {
 		kills     int
 		deaths    int
 		headshots int
+		adr       float64
+		matches   []Stats
 	}
 
 	aggregates := make(map[string]*MatchHistory) // key: PlayerID
@@ -205,37 +464,20 @@ func getMatchHistory(start, end int64, human_start, human_end string) string {
 	for _, player := range faceitPlayers {
 		// Ensure players with zero matches still appear in aggregates/sums
 		if _, ok := aggregates[player.PlayerID]; !ok {
-			aggregates[player.PlayerID] = &MatchHistory{Nickname: player.PlayerName}
+			aggregates[player.PlayerID] = &MatchHistory{PlayerID: player.PlayerID, Nickname: player.PlayerName}
 		}
 		if _, ok := sums[player.PlayerID]; !ok {
 			sums[player.PlayerID] = &runningTotals{}
 		}
-		response := QueryFACEITAPI("/players/"+player.PlayerID+"/games/cs2/stats", map[string]interface{}{
-			"from":   start,
-			"to":     end,
-			"offset": 0,
-			"limit":  30,
-		})
-		body, err := io.ReadAll(response.Body)
-		if err != nil {
-			log.Fatal("Error reading response body: ", err)
-		}
-		response.Body.Close()
-
-		var list playerStatsList
-		if err := json.Unmarshal(body, &list); err != nil {
-			log.Printf("Failed to unmarshal player %s stats: %v", player.PlayerName, err)
-			continue
-		}
-		for _, it := range list.Items {
-			s := it.Stats
-			// If TEAM_NAME is set, discard stats for that team. This will filter out league games and only include pugs
-			if teamName != "" && s.Team == teamName {
+		matches := matchesByPlayer[player.PlayerID]
+		for _, s := range matches {
+			// Discard matches rejected by the report/group filter (by default TEAM_NAME's league games)
+			if s.MatchFinishedAt < start || s.MatchFinishedAt >= end || !filter(s) {
 				continue
 			}
 			// Initialize on first encounter
 			if _, ok := aggregates[s.PlayerID]; !ok {
-				aggregates[s.PlayerID] = &MatchHistory{Nickname: s.Nickname, Team: s.Team}
+				aggregates[s.PlayerID] = &MatchHistory{PlayerID: s.PlayerID, Nickname: s.Nickname, Team: s.Team}
 			}
 			if _, ok := sums[s.PlayerID]; !ok {
 				sums[s.PlayerID] = &runningTotals{}
@@ -250,58 +492,85 @@ func getMatchHistory(start, end int64, human_start, human_end string) string {
 			} else {
 				mh.Total_Losses++
 			}
+			mh.Total_Kills += s.Kills
+			mh.Total_MVPs += s.MVPs
+			mh.Total_Quadro_Kills += s.QuadroKills
+			mh.Total_Penta_Kills += s.PentaKills
 
 			rt.kills += s.Kills
 			rt.deaths += s.Deaths
 			rt.headshots += s.Headshots
+			rt.adr += s.ADR
+			rt.matches = append(rt.matches, s)
 		}
 	}
 
-	//Sort the playerID by Total Matches
-	playerIDs := make([]string, 0, len(aggregates))
-	for playerID := range aggregates {
-		playerIDs = append(playerIDs, playerID)
+	// Finalize computed ratios
+	eloChange := eloChanges(time.UnixMilli(start), time.UnixMilli(end))
+	rows := make([]*MatchHistory, 0, len(aggregates))
+	for playerID, mh := range aggregates {
+		mh.ELO_Change = eloChange[playerID]
+		if rt := sums[playerID]; rt != nil {
+			if rt.deaths > 0 {
+				mh.Total_KDRatio = float64(rt.kills) / float64(rt.deaths)
+			}
+			if rt.kills > 0 {
+				mh.Total_HS_Percentage = (float64(rt.headshots) / float64(rt.kills)) * 100.0
+			}
+			if mh.Total_Matches > 0 {
+				mh.Total_ADR = rt.adr / float64(mh.Total_Matches)
+			}
+			st := computeStreaks(rt.matches)
+			mh.Longest_Win_Streak, mh.Longest_Loss_Streak = st.LongestWin, st.LongestLoss
+			mh.Current_Streak = currentStreak(playerID, matchesByPlayer[playerID], start, end)
+		}
+		rows = append(rows, mh)
 	}
 
 	// Sort by Total_Matches desc, then Nickname case-insensitive asc
-	sort.Slice(playerIDs, func(i, j int) bool {
-		ai := aggregates[playerIDs[i]]
-		aj := aggregates[playerIDs[j]]
-		if ai.Total_Matches != aj.Total_Matches {
-			return ai.Total_Matches > aj.Total_Matches
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Total_Matches != rows[j].Total_Matches {
+			return rows[i].Total_Matches > rows[j].Total_Matches
 		}
-		return strings.ToLower(ai.Nickname) < strings.ToLower(aj.Nickname)
+		return strings.ToLower(rows[i].Nickname) < strings.ToLower(rows[j].Nickname)
 	})
+	return rows
+}
+
+// matchHistoryColumns formats the standard summary columns of a row
+func matchHistoryColumns(mh *MatchHistory) []string {
+	if mh.Total_Matches == 0 {
+		return []string{mh.Nickname, "0", "0-0", "0.00", "0.0"}
+	}
+	return []string{mh.Nickname, strconv.Itoa(mh.Total_Matches), fmt.Sprintf("%d-%d", mh.Total_Wins, mh.Total_Losses), fmt.Sprintf("%.2f", mh.Total_KDRatio), fmt.Sprintf("%.1f", mh.Total_HS_Percentage)}
+}
 
+// renderMatchHistory renders sorted rows as the summary table, with each position's movement against the
+// previous window's positions. With previousRows set, the week-over-week delta columns are added.
+func renderMatchHistory(rows []*MatchHistory, previous map[string]int, previousRows map[string]*MatchHistory) string {
 	var builder bytes.Buffer
-	builder.Reset()
 	table := tablewriter.NewTable(&builder)
-	table.Header([]string{"NAME", "MATCHES", "W-L", "KD", "HS%"})
-
-	// Finalize computed ratios and log
-	for _, playerID := range playerIDs {
-		mh := aggregates[playerID]
-		rt := sums[playerID]
-		if rt != nil {
-			if rt.deaths > 0 {
-				mh.Total_KDRatio = float64(rt.kills) / float64(rt.deaths)
-			}
-			if rt.kills > 0 {
-				mh.Total_HS_Percentage = (float64(rt.headshots) / float64(rt.kills)) * 100.0
-			}
+	header := []string{"#", "NAME", "MATCHES", "W-L", "KD", "HS%", "STREAK"}
+	if previousRows != nil {
+		for _, column := range deltaColumns {
+			header = append(header, column.Header)
 		}
-		if mh.Total_Matches > 0 {
-			table.Append([]string{mh.Nickname, strconv.Itoa(mh.Total_Matches), fmt.Sprintf("%d-%d", mh.Total_Wins, mh.Total_Losses), fmt.Sprintf("%.2f", mh.Total_KDRatio), fmt.Sprintf("%.1f", mh.Total_HS_Percentage)})
-			// discordMessage += fmt.Sprintf("**%s**: Matches %d, W-L %d-%d, KD %.2f, HS%% %.1f\n",
-			// mh.Nickname, mh.Total_Matches, mh.Total_Wins, mh.Total_Losses, mh.Total_KDRatio, mh.Total_HS_Percentage)
-		} else {
-			table.Append([]string{mh.Nickname, "0", "0-0", "0.00", "0.0"})
-			// discordMessage += fmt.Sprintf("**%s**: No matches played this week\n", mh.Nickname)
+	}
+	table.Header(header)
+	banned := activeBans()
+	for n, mh := range rows {
+		columns := matchHistoryColumns(mh)
+		if _, ok := banned[mh.PlayerID]; ok {
+			columns[0] += bannedLabel
 		}
-
+		columns = append([]string{formatRank(n+1, previous, mh)}, columns...)
+		columns = append(columns, formatStreak(mh.Current_Streak))
+		if previousRows != nil {
+			columns = append(columns, deltaCells(mh, previousRows)...)
+		}
+		table.Append(columns)
 	}
 	table.Render()
-	table = nil
 	return builder.String()
 }
 
@@ -311,7 +580,11 @@ func getMatchHistory(start, end int64, human_start, human_end string) string {
 
 // get a player's detailed stats over the last 7 days
 // get a player's detailed league stats over the last 3 months
-func ListPlayers() string {
+// ListPlayers lists the tracked players with their groups in a guild, or in the summary guild if guildID is empty
+func ListPlayers(guildID string) string {
+	if guildID == "" {
+		guildID = summaryGuildID()
+	}
 	var players []FACEITPlayers = []FACEITPlayers{}
 	players = getPlayerIDs()
 	// Sort players by PlayerName case-insensitive asc
@@ -321,9 +594,15 @@ func ListPlayers() string {
 	var builder bytes.Buffer
 	builder.Reset()
 	table := tablewriter.NewTable(&builder)
-	table.Header([]string{"NAME", "ID"})
+	groups := playerGroupNames(guildGroups(guildID))
+	banned := activeBans()
+	table.Header([]string{"NAME", "ID", "GROUPS", "STATUS"})
 	for _, player := range players {
-		table.Append([]string{player.PlayerName, player.PlayerID})
+		status := ""
+		if ban, ok := banned[player.PlayerID]; ok {
+			status = "banned (" + ban.Type + ")"
+		}
+		table.Append([]string{player.PlayerName, player.PlayerID, strings.Join(groups[strings.ToLower(player.PlayerName)], ", "), status})
 	}
 	table.Render()
 	table = nil
@@ -331,81 +610,157 @@ func ListPlayers() string {
 }
 
 func AddPlayer(playerName string) string {
-	// Load existing names in the expected structure
-	names := loadPlayerJSON()
-
-	// Check if the player already exists
-	for _, name := range names.PlayerName {
-		if name == playerName {
-			return "Player already exists: " + playerName
-		}
+	// Resolve the nickname on FACEIT and reject unknown, non-CS2 and duplicate players
+	playerName = strings.TrimSpace(playerName)
+	profile, err := validateNewPlayer(playerName, getPlayerIDs())
+	if err != nil {
+		return addPlayerMessage(playerName, err)
 	}
+	return addPlayerMessage(profile.Nickname, addTrackedPlayer(profile.Nickname))
+}
 
-	// Append and sort case-insensitively
-	names.PlayerName = append(names.PlayerName, playerName)
-	sort.Slice(names.PlayerName, func(i, j int) bool {
-		return strings.ToLower(names.PlayerName[i]) < strings.ToLower(names.PlayerName[j])
+// addTrackedPlayer stores a validated nickname, unless it was added meanwhile
+func addTrackedPlayer(nickname string) error {
+	var err error
+	updatePlayerJSON(func(names *FACEITPlayerNames) bool {
+		if containsName(names.PlayerName, nickname) {
+			err = errors.New("already tracked")
+			return false
+		}
+		names.PlayerName = append(names.PlayerName, nickname)
+		return true
 	})
+	return err
+}
 
-	// Persist to data/faceit_player_names.json with the correct structure
-	data, err := json.MarshalIndent(names, "", "    ")
-	if err != nil {
-		log.Fatal("Error marshalling player names: ", err)
-	}
-	if err := os.WriteFile("data/faceit_player_names.json", data, 0644); err != nil {
-		log.Fatal("Error writing faceit_player_names.json: ", err)
+// containsName reports whether names holds name, case-insensitive
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
 	}
-
-	return "Player added: " + playerName
+	return false
 }
 
 func RemovePlayer(playerName string) string {
-	names := loadPlayerJSON()
-	for i, name := range names.PlayerName {
-		if name == playerName {
-			names.PlayerName = append(names.PlayerName[:i], names.PlayerName[i+1:]...)
-			break
+	removed := ""
+	updatePlayerJSON(func(names *FACEITPlayerNames) bool {
+		for i, name := range names.PlayerName {
+			if strings.EqualFold(name, playerName) {
+				removed = name
+				names.PlayerName = append(names.PlayerName[:i], names.PlayerName[i+1:]...)
+				break
+			}
 		}
+		return removed != ""
+	})
+	if removed == "" {
+		return "Player is not tracked: " + playerName
 	}
-	data, err := json.MarshalIndent(names, "", "    ")
-	if err != nil {
-		log.Fatal("Error marshalling player names: ", err)
-	}
-	if err := os.WriteFile("data/faceit_player_names.json", data, 0644); err != nil {
-		log.Fatal("Error writing faceit_player_names.json: ", err)
-	}
-	return "Player removed: " + playerName
+	removeGroupMemberEverywhere(removed)
+	return "Player removed: " + removed
 }
 
-func FACEITInit(s *discordgo.Session) string {
+func FACEITInit(s *discordgo.Session, sortOverride string) string {
+	guildID := reportGuildID(s)
+	groups := reportGroups(guildID)
 
 	// LAST WEEK
-	start, end, human_start, human_end := CurrentWeekWindow(time.Now().AddDate(0, 0, -7))
+	awardSettings := getGuildSettings(guildID).Awards
+	sortKey := reportSortKey("last-week", sortOverride)
+	currentWindow := CurrentWeekWindow(time.Now())
+	window := currentWindow.Previous()
+	start, end, human_start, human_end := window.StartMillis(), window.EndMillis(), window.HumanStart(), window.HumanEnd()
+	previousStart := window.Previous().StartMillis()
 	log.Printf("Last Week: %s -> %s", human_start, human_end)
-	discordMessage := getMatchHistory(start, end, human_start, human_end)
-	marker := "**Last Week -- Match History**: " + human_start + " -> " + human_end
-	msg := marker + "\n\n" + "```" + discordMessage + "```"
-	UpdateMessage(s, msg, marker)
-	// UpdatePresence(s, msg, marker)
+	lastWeekRows := make(map[string][]*MatchHistory) // key: group name
+	for _, group := range groups {
+		marker := "**Last Week -- Match History" + group.markerSuffix() + "**: " + human_start + " -> " + human_end
+		filter, err := compileFilter(reportFilterExpression("last-week", group.Filter))
+		if err != nil {
+			log.Printf("Invalid last-week filter%s: %v", group.markerSuffix(), err)
+			UpdateMessage(s, invalidFilterMessage(marker, err), marker)
+			continue
+		}
+		rows := aggregateMatchHistory(group.Players, filter, start, end)
+		lastWeekRows[group.Name] = rows
+		sortMatchHistory(rows, sortKey)
+		recordRankPositions(group.Name, "last-week", sortKey, start, rows)
+		discordMessage := renderMatchHistory(rows, rankPositions(group.Name, "last-week", sortKey, previousStart), nil)
+		msg := marker + sortCaption(sortKey) + "\n\n" + "```" + discordMessage + "```"
+		// The finished week gets the awards section
+		if awardsSection := renderAwards(rows, awardSettings); awardsSection != "" {
+			msg += "\n" + awardsSection
+		}
+		UpdateMessage(s, truncateMessage(msg), marker)
+		// UpdatePresence(s, msg, marker)
+	}
 
 	// CURRENT WEEK
-	start, end, human_start, human_end = CurrentWeekWindow(time.Now())
+	sortKey = reportSortKey("current-week", sortOverride)
+	showDeltas := reportDeltasEnabled()
+	previousStart = start
+	start, end, human_start, human_end = currentWindow.StartMillis(), currentWindow.EndMillis(), currentWindow.HumanStart(), currentWindow.HumanEnd()
 	log.Printf("Current Week: %s -> %s", human_start, human_end)
-	discordMessage = getMatchHistory(start, end, human_start, human_end)
-	marker = "**Current Week -- Match History**: " + human_start + " -> " + human_end
-	msg = marker + "\n\n" + "```" + discordMessage + "```"
-	UpdateMessage(s, msg, marker)
-	// UpdatePresence(s, msg, marker)
-	discordMessage = ""
-	msg = ""
+	for _, group := range groups {
+		marker := "**Current Week -- Match History" + group.markerSuffix() + "**: " + human_start + " -> " + human_end
+		filter, err := compileFilter(reportFilterExpression("current-week", group.Filter))
+		if err != nil {
+			log.Printf("Invalid current-week filter%s: %v", group.markerSuffix(), err)
+			UpdateMessage(s, invalidFilterMessage(marker, err), marker)
+			continue
+		}
+		rows := aggregateMatchHistory(group.Players, filter, start, end)
+		sortMatchHistory(rows, sortKey)
+		recordRankPositions(group.Name, "current-week", sortKey, start, rows)
+		var previousRows map[string]*MatchHistory
+		if showDeltas {
+			// Last week's rows can be reused when both reports count the same matches
+			lastWeek, ok := lastWeekRows[group.Name]
+			if !ok || reportFilterExpression("last-week", group.Filter) != reportFilterExpression("current-week", group.Filter) {
+				lastWeek = aggregateMatchHistory(group.Players, filter, previousStart, start)
+			}
+			previousRows = rowsByPlayer(lastWeek)
+		}
+		positions := rankPositions(group.Name, "current-week", sortKey, previousStart)
+		msg := ""
+		if previousRows != nil {
+			// The delta columns are colored, which needs an ansi block
+			msg = marker + sortCaption(sortKey) + "\n\n" + "```ansi\n" + renderMatchHistory(rows, positions, previousRows) + "```"
+		}
+		if msg == "" || len(msg) > maxMessageLength {
+			// Without room for the delta columns the table keeps its players instead
+			msg = marker + sortCaption(sortKey) + "\n\n" + "```" + renderMatchHistory(rows, positions, nil) + "```"
+			if previousRows != nil {
+				msg += deltasOmittedNote
+			}
+		}
+		UpdateMessage(s, truncateMessage(msg), marker)
+		// UpdatePresence(s, msg, marker)
+	}
+
+	// LEAGUE
+	UpdateLeagueReport(s)
 	log.Println("Listening and READY")
 	return "Refreshed!"
 }
 
 // StartFACEITRefresher runs FACEIT refresh immediately and then every hour until stopCh is closed.
+// Every run also records ELO snapshots and syncs level roles from them.
 func StartFACEITRefresher(s *discordgo.Session, stopCh <-chan struct{}) {
 	run := func() {
-		FACEITInit(s)
+		FACEITInit(s, "")
+		SyncLevelRoles(s, TakeELOSnapshots())
+		UpdateHubStandings(s)
+		TakeRankSnapshots()
+		AlertNewBans(s, CheckBans())
+		CheckStreakAlerts(s)
+		if dir := config.Dashboard.Dir; dir != "" {
+			if err := GenerateDashboard(dir); err != nil {
+				log.Println("Error generating dashboard", err)
+			}
+		}
 	}
 
 	// Run once immediately
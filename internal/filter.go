@@ -0,0 +1,483 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A compiled match filter expression. It reports whether a match counts towards a report.
+//
+// Expressions compare Stats fields with = or != and combine terms with AND, OR, NOT and parentheses, e.g.
+//
+//	mode=5v5 AND competition!=<league id>
+//	(map=de_mirage OR map=de_inferno) AND NOT team="Lurker Gaming"
+//
+// A bare word names a preset, so `pugs-only AND bestof=1` expands the pugs-only preset. $TEAM_NAME in a
+// value is replaced by the configured team name (team_name / TEAM_NAME). Comparisons are case-insensitive.
+type matchFilter func(s Stats) bool
+
+// Fields usable in filter expressions
+var filterFields = map[string]func(s Stats) string{
+	"competition": func(s Stats) string { return s.CompetitionID },
+	"mode":        func(s Stats) string { return s.GameMode },
+	"region":      func(s Stats) string { return s.Region },
+	"bestof":      func(s Stats) string { return strconv.Itoa(s.BestOf) },
+	"team":        func(s Stats) string { return s.Team },
+	"map":         func(s Stats) string { return s.Map },
+	"result": func(s Stats) string {
+		if s.Result == 1 {
+			return "win"
+		}
+		return "loss"
+	},
+}
+
+// Built-in presets. Custom presets saved with /filter preset are stored in data/filters.json.
+var builtinFilterPresets = map[string]string{
+	"all":         "",
+	"pugs-only":   "team!=$TEAM_NAME",
+	"league-only": "team=$TEAM_NAME",
+}
+
+// Report slots that can carry their own filter
+var reportSlots = []string{"last-week", "current-week"}
+
+// Per-report settings
+type ReportConfig struct {
+	Filter string `json:"filter,omitempty"`
+	Sort   string `json:"sort,omitempty"`   // summary table order, see sortOrders
+	Deltas bool   `json:"deltas,omitempty"` // week-over-week columns, current-week only
+}
+
+// This struct is the layout of data/filters.json
+type filterSettings struct {
+	Reports map[string]ReportConfig `json:"reports"` // key: report slot
+	Presets map[string]string       `json:"presets"` // key: preset name
+}
+
+const filterSettingsFile = "filters.json"
+
+var filterMu sync.Mutex
+
+func loadFilterSettings() filterSettings {
+	settings := filterSettings{}
+	if err := readDataFile(filterSettingsFile, &settings); err != nil {
+		log.Println("Error reading", filterSettingsFile, err)
+	}
+	if settings.Reports == nil {
+		settings.Reports = map[string]ReportConfig{}
+	}
+	if settings.Presets == nil {
+		settings.Presets = map[string]string{}
+	}
+	return settings
+}
+
+func updateFilterSettings(update func(settings *filterSettings)) error {
+	filterMu.Lock()
+	defer filterMu.Unlock()
+	settings := loadFilterSettings()
+	update(&settings)
+	return writeDataFile(filterSettingsFile, settings)
+}
+
+// filterPresets returns the built-in presets overlaid with the custom ones
+func filterPresets() map[string]string {
+	filterMu.Lock()
+	defer filterMu.Unlock()
+	presets := make(map[string]string)
+	for name, expr := range builtinFilterPresets {
+		presets[name] = expr
+	}
+	for name, expr := range loadFilterSettings().Presets {
+		presets[name] = expr
+	}
+	return presets
+}
+
+// reportFilterExpression combines the report slot's filter with the group's filter. With neither set the
+// old TEAM_NAME behaviour applies: matches played for TEAM_NAME are excluded. Setting either filter
+// replaces that default, so a filter that should still leave league matches out has to say so, e.g.
+// `pugs-only AND bestof=1`.
+func reportFilterExpression(slot, groupFilter string) string {
+	filterMu.Lock()
+	reportFilter := loadFilterSettings().Reports[slot].Filter
+	filterMu.Unlock()
+	switch {
+	case reportFilter != "" && groupFilter != "":
+		return "(" + reportFilter + ") AND (" + groupFilter + ")"
+	case reportFilter != "":
+		return reportFilter
+	case groupFilter != "":
+		return groupFilter
+	case config.TeamName != "":
+		return "pugs-only"
+	}
+	return ""
+}
+
+type filterToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '=':
+			tokens = append(tokens, filterToken{text: string(r)})
+			i++
+		case r == '!':
+			if i+1 >= len(runes) || runes[i+1] != '=' {
+				return nil, fmt.Errorf("expected != at position %d", i+1)
+			}
+			tokens = append(tokens, filterToken{text: "!="})
+			i += 2
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated quote at position %d", i+1)
+			}
+			tokens = append(tokens, filterToken{text: string(runes[i+1 : end]), quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()=!"`, runes[end]) {
+				end++
+			}
+			tokens = append(tokens, filterToken{text: string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// Recursive descent parser: or := and {OR and}; and := unary {AND unary}; unary := NOT unary | primary;
+// primary := ( or ) | field (=|!=) value | preset
+type filterParser struct {
+	tokens  []filterToken
+	pos     int
+	presets map[string]string
+	depth   int
+}
+
+func (p *filterParser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+func (p *filterParser) next() (filterToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	p.pos++
+	return p.tokens[p.pos-1], true
+}
+
+func (p *filterParser) parseOr() (matchFilter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s Stats) bool { return l(s) || right(s) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (matchFilter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("AND") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s Stats) bool { return l(s) && right(s) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (matchFilter, error) {
+	if p.peekKeyword("NOT") {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(s Stats) bool { return !inner(s) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (matchFilter, error) {
+	tok, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+	if tok.text == "(" && !tok.quoted {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.next(); !ok || closing.text != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return inner, nil
+	}
+
+	op, hasOp := filterToken{}, false
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && (p.tokens[p.pos].text == "=" || p.tokens[p.pos].text == "!=") {
+		op, hasOp = p.tokens[p.pos], true
+	}
+	if !hasOp {
+		expr, ok := p.presets[strings.ToLower(tok.text)]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset or missing comparison: %s", tok.text)
+		}
+		if p.depth >= 5 {
+			return nil, fmt.Errorf("presets nested too deeply: %s", tok.text)
+		}
+		return compileFilterDepth(expr, p.presets, p.depth+1)
+	}
+	p.pos++
+
+	field, ok := filterFields[strings.ToLower(tok.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q (fields: %s)", tok.text, strings.Join(filterFieldNames(), ", "))
+	}
+	value, ok := p.next()
+	if !ok || (!value.quoted && strings.ContainsAny(value.text, "()=")) {
+		return nil, fmt.Errorf("missing value for %s", tok.text)
+	}
+	if strings.Contains(value.text, "$TEAM_NAME") && config.TeamName == "" {
+		// An empty team would make pugs-only keep every match and league-only none
+		return nil, fmt.Errorf("%s uses $TEAM_NAME but team_name (TEAM_NAME) is not set", tok.text)
+	}
+	want := strings.ReplaceAll(value.text, "$TEAM_NAME", config.TeamName)
+	if op.text == "!=" {
+		return func(s Stats) bool { return !strings.EqualFold(field(s), want) }, nil
+	}
+	return func(s Stats) bool { return strings.EqualFold(field(s), want) }, nil
+}
+
+func filterFieldNames() []string {
+	names := make([]string, 0, len(filterFields))
+	for name := range filterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func compileFilterDepth(expr string, presets map[string]string, depth int) (matchFilter, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return func(Stats) bool { return true }, nil
+	}
+	p := &filterParser{tokens: tokens, presets: presets, depth: depth}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return filter, nil
+}
+
+// compileFilter parses a filter expression. An empty expression matches every match.
+func compileFilter(expr string) (matchFilter, error) {
+	return compileFilterDepth(expr, filterPresets(), 0)
+}
+
+// invalidFilterMessage replaces a summary table whose filter no longer compiles, so the channel shows why
+// the table stopped updating
+func invalidFilterMessage(marker string, err error) string {
+	return marker + "\n\nThis table is not updating, its filter is invalid: " + err.Error() +
+		"\nFix it with `/filter report`, `/filter group` or `/filter preset`, see `/filter list`."
+}
+
+// brokenFilters lists the report and group filters that would fail to compile with the given presets
+func brokenFilters(reports map[string]ReportConfig, presets map[string]string) []string {
+	var broken []string
+	for _, slot := range reportSlots {
+		if expr := reports[slot].Filter; expr != "" {
+			if _, err := compileFilterDepth(expr, presets, 0); err != nil {
+				broken = append(broken, "report "+slot)
+			}
+		}
+	}
+	// Presets are shared, so every guild's groups count
+	groups := loadPlayerJSON().Groups
+	for _, settings := range allGuildSettings() {
+		groups = append(groups, settings.Groups...)
+	}
+	for _, group := range groups {
+		if group.Filter != "" {
+			if _, err := compileFilterDepth(group.Filter, presets, 0); err != nil {
+				broken = append(broken, "group "+group.Name)
+			}
+		}
+	}
+	return broken
+}
+
+func renderFilterSettings(guildID string) string {
+	filterMu.Lock()
+	settings := loadFilterSettings()
+	filterMu.Unlock()
+	var lines []string
+	lines = append(lines, "**Reports**")
+	for _, slot := range reportSlots {
+		expr := settings.Reports[slot].Filter
+		if expr == "" {
+			expr = "(default)"
+		}
+		line := fmt.Sprintf("%s: `%s`", slot, expr)
+		if key := settings.Reports[slot].Sort; key != "" {
+			line += ", sorted by " + key
+		}
+		if settings.Reports[slot].Deltas {
+			line += ", with weekly changes"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "**Groups**")
+	for _, group := range guildGroups(guildID) {
+		expr := group.Filter
+		if expr == "" {
+			expr = "(none)"
+		}
+		lines = append(lines, fmt.Sprintf("%s: `%s`", group.Name, expr))
+	}
+	lines = append(lines, "**Presets**")
+	presets := filterPresets()
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: `%s`", name, presets[name]))
+	}
+	if config.TeamName != "" {
+		lines = append(lines, "Default when neither a report nor a group filter is set: `pugs-only` (TEAM_NAME = "+config.TeamName+")")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func handleFilter(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionsByName(sub.Options)
+	expr := ""
+	if opt, ok := opts["expression"]; ok {
+		expr = strings.TrimSpace(opt.StringValue())
+	}
+	if expr != "" {
+		if _, err := compileFilter(expr); err != nil {
+			respondEphemeral(s, i, "Invalid filter: "+err.Error())
+			return
+		}
+	}
+
+	content := ""
+	var err error
+	switch sub.Name {
+	case "report":
+		slot := opts["report"].StringValue()
+		err = updateFilterSettings(func(settings *filterSettings) {
+			cfg := settings.Reports[slot]
+			cfg.Filter = expr
+			settings.Reports[slot] = cfg
+		})
+		content = fmt.Sprintf("Filter for %s set to `%s`", slot, expr)
+	case "group":
+		content = SetGroupFilter(i.GuildID, opts["group"].StringValue(), expr)
+	case "preset":
+		name := strings.ToLower(opts["name"].StringValue())
+		if _, builtin := builtinFilterPresets[name]; builtin || strings.ContainsAny(name, ` ()="!`) ||
+			strings.EqualFold(name, "and") || strings.EqualFold(name, "or") || strings.EqualFold(name, "not") {
+			respondEphemeral(s, i, "Preset names cannot shadow built-in presets, keywords or contain spaces/operators.")
+			return
+		}
+		// Refuse changes that would break a filter using the preset, since that table would stop updating
+		var broken []string
+		err = updateFilterSettings(func(settings *filterSettings) {
+			current := make(map[string]string)
+			for name, expr := range builtinFilterPresets {
+				current[name] = expr
+			}
+			for name, expr := range settings.Presets {
+				current[name] = expr
+			}
+			presets := maps.Clone(current)
+			if expr == "" {
+				delete(presets, name)
+			} else {
+				presets[name] = expr
+			}
+			alreadyBroken := brokenFilters(settings.Reports, current)
+			for _, label := range brokenFilters(settings.Reports, presets) {
+				if !slices.Contains(alreadyBroken, label) {
+					broken = append(broken, label)
+				}
+			}
+			if len(broken) > 0 {
+				return
+			}
+			if expr == "" {
+				delete(settings.Presets, name)
+			} else {
+				settings.Presets[name] = expr
+			}
+		})
+		switch {
+		case len(broken) > 0:
+			content = fmt.Sprintf("Preset %s is used by %s; change those filters first.", name, strings.Join(broken, ", "))
+		case expr == "":
+			content = "Preset " + name + " deleted"
+		default:
+			content = fmt.Sprintf("Preset %s set to `%s`", name, expr)
+		}
+	case "list":
+		content = renderFilterSettings(i.GuildID)
+	}
+	if err != nil {
+		content = "Could not save the filter, try again later."
+	}
+	respondEphemeral(s, i, truncateMessage(content))
+}
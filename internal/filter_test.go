@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileFilter(t *testing.T) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+	config = &Config{TeamName: "Lurker Gaming"}
+
+	presets := map[string]string{
+		"all":         "",
+		"pugs-only":   "team!=$TEAM_NAME",
+		"league-only": "team=$TEAM_NAME",
+		"mirage":      "map=de_mirage",
+		"mirage-pugs": "mirage AND pugs-only",
+		"loop":        "loop",
+	}
+	pug := Stats{Map: "de_mirage", GameMode: "5v5", BestOf: 1, Result: 1}
+	league := Stats{Map: "de_inferno", GameMode: "5v5", BestOf: 3, Team: "Lurker Gaming"}
+	wingman := Stats{Map: "de_mirage", GameMode: "2v2", Team: "lurker gaming"}
+
+	tests := []struct {
+		expr    string
+		matches []Stats
+		skips   []Stats
+	}{
+		{"", []Stats{pug, league, wingman}, nil},
+		{"all", []Stats{pug, league, wingman}, nil},
+		{"mode=5v5", []Stats{pug, league}, []Stats{wingman}},
+		{"MODE = 5V5", []Stats{pug, league}, []Stats{wingman}},
+		{"map!=de_mirage", []Stats{league}, []Stats{pug, wingman}},
+		{"bestof=3", []Stats{league}, []Stats{pug, wingman}},
+		{"result=win", []Stats{pug}, []Stats{league, wingman}},
+		{`team="Lurker Gaming"`, []Stats{league, wingman}, []Stats{pug}},
+		// AND binds tighter than OR
+		{"map=de_inferno OR map=de_mirage AND mode=2v2", []Stats{league, wingman}, []Stats{pug}},
+		{"(map=de_inferno OR map=de_mirage) AND mode=5v5", []Stats{pug, league}, []Stats{wingman}},
+		{"NOT mode=2v2", []Stats{pug, league}, []Stats{wingman}},
+		{"NOT NOT mode=2v2", []Stats{wingman}, []Stats{pug, league}},
+		{"NOT mode=2v2 AND map=de_mirage", []Stats{pug}, []Stats{league, wingman}},
+		{"not (mode=2v2 or bestof=3)", []Stats{pug}, []Stats{league, wingman}},
+		// Quoted keywords are values, not operators
+		{`map="OR" OR mode=2v2`, []Stats{wingman}, []Stats{pug, league}},
+		{"pugs-only", []Stats{pug}, []Stats{league, wingman}},
+		{"league-only", []Stats{league, wingman}, []Stats{pug}},
+		{"mirage-pugs", []Stats{pug}, []Stats{league, wingman}},
+		{"Mirage AND NOT pugs-only", []Stats{wingman}, []Stats{pug, league}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := compileFilterDepth(tt.expr, presets, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.matches {
+				if !filter(s) {
+					t.Errorf("%+v doesn't match, want a match", s)
+				}
+			}
+			for _, s := range tt.skips {
+				if filter(s) {
+					t.Errorf("%+v matches, want it skipped", s)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	saved := config
+	t.Cleanup(func() { config = saved })
+	config = &Config{TeamName: "Lurker Gaming"}
+
+	presets := map[string]string{
+		"pugs-only": "team!=$TEAM_NAME",
+		"loop":      "loop",
+	}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"rank=1", "unknown field"},
+		{"mode<5v5", "unknown preset"},
+		{"mode!5v5", "expected !="},
+		{"mode=", "missing value"},
+		{"mode=(", "missing value"},
+		{`team="Lurker`, "unterminated quote"},
+		{"(mode=5v5", "missing )"},
+		{"mode=5v5)", "unexpected"},
+		{"mode=5v5 AND", "unexpected end"},
+		{"mode=5v5 map=de_dust2", "unexpected"},
+		{"no-such-preset", "unknown preset"},
+		{"loop", "nested too deeply"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := compileFilterDepth(tt.expr, presets, 0)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("compileFilterDepth(%q) error = %v, want it to mention %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+
+	config = &Config{}
+	for _, expr := range []string{"pugs-only", "team=$TEAM_NAME"} {
+		if _, err := compileFilterDepth(expr, presets, 0); err == nil || !strings.Contains(err.Error(), "TEAM_NAME") {
+			t.Errorf("compileFilterDepth(%q) without a team name error = %v, want it to mention TEAM_NAME", expr, err)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A resolved group ready for reporting. The default group (empty Name) holds every tracked player that is
+// not a member of any named group.
+type reportGroup struct {
+	Name    string
+	Players []FACEITPlayers
+	Filter  string
+}
+
+// markerSuffix distinguishes a group's summary message from the other groups' messages
+func (g reportGroup) markerSuffix() string {
+	if g.Name == "" {
+		return ""
+	}
+	return " [" + g.Name + "]"
+}
+
+// reportGroups resolves the tracked players once and splits them into the guild's groups. Without any groups
+// configured this is a single default group of all players, matching the original single table.
+func reportGroups(guildID string) []reportGroup {
+	playerGroups := guildGroups(guildID)
+	players := getPlayerIDs()
+	byName := make(map[string]FACEITPlayers, len(players))
+	for _, player := range players {
+		byName[strings.ToLower(player.PlayerName)] = player
+	}
+
+	grouped := make(map[string]bool)
+	var groups []reportGroup
+	for _, group := range playerGroups {
+		rg := reportGroup{Name: group.Name, Filter: group.Filter}
+		for _, member := range group.Members {
+			if player, ok := byName[strings.ToLower(member)]; ok {
+				rg.Players = append(rg.Players, player)
+				grouped[strings.ToLower(member)] = true
+			}
+		}
+		groups = append(groups, rg)
+	}
+
+	var ungrouped reportGroup
+	for _, player := range players {
+		if !grouped[strings.ToLower(player.PlayerName)] {
+			ungrouped.Players = append(ungrouped.Players, player)
+		}
+	}
+	if len(playerGroups) == 0 || len(ungrouped.Players) > 0 {
+		groups = append([]reportGroup{ungrouped}, groups...)
+	}
+	return groups
+}
+
+// guildGroups returns the groups of a guild. Groups from before they were kept per guild are still read from
+// the player list until the bot moves them into the summary guild's settings on startup.
+func guildGroups(guildID string) []PlayerGroup {
+	if groups := getGuildSettings(guildID).Groups; len(groups) > 0 {
+		return groups
+	}
+	return loadPlayerJSON().Groups
+}
+
+// summaryGuildID returns the guild whose groups split the reports that are built without a Discord session
+// (the API, the dashboard and exports): the configured guild, the update channel's guild once the bot has
+// looked it up, or else the only guild that has groups
+func summaryGuildID() string {
+	if config.Discord.GuildID != "" {
+		return config.Discord.GuildID
+	}
+	if id := cachedReportGuildID(); id != "" {
+		return id
+	}
+	guildID := ""
+	for id, settings := range allGuildSettings() {
+		if len(settings.Groups) > 0 {
+			if guildID != "" {
+				return ""
+			}
+			guildID = id
+		}
+	}
+	return guildID
+}
+
+// migrateLegacyGroups moves the groups that used to be shared by every guild into the summary guild's settings.
+// A legacy group whose name the guild already uses stays in the player list, with a warning.
+func migrateLegacyGroups(guildID string) {
+	legacy := loadPlayerJSON().Groups
+	if len(legacy) == 0 || guildID == "" {
+		return
+	}
+	moved := make(map[string]bool)
+	err := updateGuildSettings(guildID, func(settings *GuildSettings) {
+		for _, group := range legacy {
+			if findGroup(settings.Groups, group.Name) < 0 {
+				settings.Groups = append(settings.Groups, group)
+				moved[strings.ToLower(group.Name)] = true
+			}
+		}
+	})
+	if err != nil {
+		log.Println("Error moving groups to guild", guildID, err)
+		return
+	}
+	var kept []string
+	updatePlayerJSON(func(names *FACEITPlayerNames) bool {
+		remaining := names.Groups[:0]
+		for _, group := range names.Groups {
+			if !moved[strings.ToLower(group.Name)] {
+				remaining = append(remaining, group)
+				kept = append(kept, group.Name)
+			}
+		}
+		names.Groups = remaining
+		return len(moved) > 0
+	})
+	if len(moved) > 0 {
+		log.Printf("Moved %d groups to guild %s", len(moved), guildID)
+	}
+	if len(kept) > 0 {
+		log.Printf("Warning: guild %s already has groups named %s, the shared ones stay in faceit_player_names.json",
+			guildID, strings.Join(kept, ", "))
+	}
+}
+
+// removeName drops name (case-insensitive) from names
+func removeName(names []string, name string) []string {
+	kept := names[:0]
+	for _, n := range names {
+		if !strings.EqualFold(n, name) {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// removeGroupMemberEverywhere drops a player that is no longer tracked from every guild's groups
+func removeGroupMemberEverywhere(playerName string) {
+	for guildID, settings := range allGuildSettings() {
+		if len(settings.Groups) == 0 {
+			continue
+		}
+		err := updateGuildSettings(guildID, func(settings *GuildSettings) {
+			for g := range settings.Groups {
+				settings.Groups[g].Members = removeName(settings.Groups[g].Members, playerName)
+			}
+		})
+		if err != nil {
+			log.Println("Error removing", playerName, "from the groups of guild", guildID, err)
+		}
+	}
+}
+
+// playerGroupNames maps each lower-cased player name to the groups it belongs to
+func playerGroupNames(groups []PlayerGroup) map[string][]string {
+	names := make(map[string][]string)
+	for _, group := range groups {
+		for _, member := range group.Members {
+			names[strings.ToLower(member)] = append(names[strings.ToLower(member)], group.Name)
+		}
+	}
+	return names
+}
+
+// findGroup returns the index of the named group (case-insensitive) or -1
+func findGroup(groups []PlayerGroup, groupName string) int {
+	for i, group := range groups {
+		if strings.EqualFold(group.Name, groupName) {
+			return i
+		}
+	}
+	return -1
+}
+
+// groupChoices suggests the guild's group names containing query
+func groupChoices(guildID, query string) []*discordgo.ApplicationCommandOptionChoice {
+	query = strings.ToLower(query)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, group := range guildGroups(guildID) {
+		if strings.Contains(strings.ToLower(group.Name), query) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: group.Name, Value: group.Name})
+		}
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+	}
+	return choices
+}
+
+// updateGroups applies update to a guild's groups and returns its reply, or an error reply if saving failed
+func updateGroups(guildID string, update func(groups *[]PlayerGroup) string) string {
+	content := ""
+	err := updateGuildSettings(guildID, func(settings *GuildSettings) {
+		content = update(&settings.Groups)
+	})
+	if err != nil {
+		log.Println("Error saving groups for guild", guildID, err)
+		return "Error saving groups: " + err.Error()
+	}
+	return content
+}
+
+func CreateGroup(guildID, groupName, filter string) string {
+	groupName = strings.TrimSpace(groupName)
+	if groupName == "" || strings.ContainsAny(groupName, "[]*`") {
+		return "Group names cannot be empty or contain [ ] * `"
+	}
+	return updateGroups(guildID, func(groups *[]PlayerGroup) string {
+		if i := findGroup(*groups, groupName); i >= 0 {
+			return "Group already exists: " + (*groups)[i].Name
+		}
+		*groups = append(*groups, PlayerGroup{Name: groupName, Members: []string{}, Filter: filter})
+		return "Group created: " + groupName
+	})
+}
+
+func DeleteGroup(guildID, groupName string) string {
+	return updateGroups(guildID, func(groups *[]PlayerGroup) string {
+		i := findGroup(*groups, groupName)
+		if i < 0 {
+			return "Group not found: " + groupName
+		}
+		deleted := (*groups)[i].Name
+		*groups = append((*groups)[:i], (*groups)[i+1:]...)
+		return "Group deleted: " + deleted
+	})
+}
+
+func AddGroupMember(guildID, groupName, playerName string) string {
+	tracked := ""
+	for _, name := range loadPlayerJSON().PlayerName {
+		if strings.EqualFold(name, playerName) {
+			tracked = name
+		}
+	}
+	if tracked == "" {
+		return "Player is not tracked: " + playerName + " (use `/add-player` first)"
+	}
+	return updateGroups(guildID, func(groups *[]PlayerGroup) string {
+		i := findGroup(*groups, groupName)
+		if i < 0 {
+			return "Group not found: " + groupName
+		}
+		group := &(*groups)[i]
+		for _, member := range group.Members {
+			if strings.EqualFold(member, tracked) {
+				return tracked + " is already in " + group.Name
+			}
+		}
+		group.Members = append(group.Members, tracked)
+		sort.Slice(group.Members, func(a, b int) bool {
+			return strings.ToLower(group.Members[a]) < strings.ToLower(group.Members[b])
+		})
+		return tracked + " added to " + group.Name
+	})
+}
+
+func RemoveGroupMember(guildID, groupName, playerName string) string {
+	return updateGroups(guildID, func(groups *[]PlayerGroup) string {
+		i := findGroup(*groups, groupName)
+		if i < 0 {
+			return "Group not found: " + groupName
+		}
+		group := &(*groups)[i]
+		before := len(group.Members)
+		group.Members = removeName(group.Members, playerName)
+		if len(group.Members) == before {
+			return playerName + " is not in " + group.Name
+		}
+		return playerName + " removed from " + group.Name
+	})
+}
+
+// SetGroupFilter replaces a group's match filter expression. An empty expression clears it.
+func SetGroupFilter(guildID, groupName, filter string) string {
+	return updateGroups(guildID, func(groups *[]PlayerGroup) string {
+		i := findGroup(*groups, groupName)
+		if i < 0 {
+			return "Group not found: " + groupName
+		}
+		(*groups)[i].Filter = filter
+		return fmt.Sprintf("Filter for %s set to `%s`", (*groups)[i].Name, filter)
+	})
+}
+
+func ListGroups(guildID string) string {
+	groups := guildGroups(guildID)
+	if len(groups) == 0 {
+		return "No groups configured, all players share one summary table. Use `/group create`."
+	}
+	var lines []string
+	for _, group := range groups {
+		line := fmt.Sprintf("**%s** (%d)", group.Name, len(group.Members))
+		if group.Filter != "" {
+			line += " — filter `" + group.Filter + "`"
+		}
+		if len(group.Members) > 0 {
+			line += "\n" + strings.Join(group.Members, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func handleGroup(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionsByName(sub.Options)
+	content := ""
+	switch sub.Name {
+	case "create":
+		filter := ""
+		if opt, ok := opts["filter"]; ok {
+			filter = strings.TrimSpace(opt.StringValue())
+		}
+		if _, err := compileFilter(filter); err != nil {
+			content = "Invalid filter: " + err.Error()
+			break
+		}
+		content = CreateGroup(i.GuildID, opts["group"].StringValue(), filter)
+	case "delete":
+		content = DeleteGroup(i.GuildID, opts["group"].StringValue())
+	case "add":
+		content = AddGroupMember(i.GuildID, opts["group"].StringValue(), opts["player"].StringValue())
+	case "remove":
+		content = RemoveGroupMember(i.GuildID, opts["group"].StringValue(), opts["player"].StringValue())
+	case "list":
+		content = ListGroups(i.GuildID)
+	}
+	respondEphemeral(s, i, truncateMessage(content))
+}
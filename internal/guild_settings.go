@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"log"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Per-guild settings managed through admin slash commands
+type GuildSettings struct {
+	Roles RoleMapping `json:"roles"`
+	Hub   HubSettings `json:"hub,omitempty"`
+	// Channel for alerts about new FACEIT bans of tracked players, empty to disable
+	BanAlertChannelID string              `json:"ban_alert_channel_id,omitempty"`
+	Awards            AwardSettings       `json:"awards,omitempty"`
+	StreakAlerts      StreakAlertSettings `json:"streak_alerts,omitempty"`
+	// Groups of tracked players; the summary guild's groups split the summaries, API reports and dashboard
+	Groups []PlayerGroup `json:"groups,omitempty"`
+}
+
+// This struct is the layout of data/guild_settings.json
+type guildSettingsFile struct {
+	Guilds map[string]*GuildSettings `json:"guilds"` // key: guild ID
+}
+
+const guildSettingsFileName = "guild_settings.json"
+
+var guildSettingsMu sync.Mutex
+
+func loadGuildSettingsFile() guildSettingsFile {
+	settings := guildSettingsFile{Guilds: map[string]*GuildSettings{}}
+	if err := readDataFile(guildSettingsFileName, &settings); err != nil {
+		log.Println("Error reading", guildSettingsFileName, err)
+	}
+	if settings.Guilds == nil {
+		settings.Guilds = map[string]*GuildSettings{}
+	}
+	return settings
+}
+
+// getGuildSettings returns a copy of the settings for a guild, or zero settings if none are stored
+func getGuildSettings(guildID string) GuildSettings {
+	guildSettingsMu.Lock()
+	defer guildSettingsMu.Unlock()
+	if settings, ok := loadGuildSettingsFile().Guilds[guildID]; ok {
+		return *settings
+	}
+	return GuildSettings{}
+}
+
+// allGuildSettings returns the stored settings of every guild, keyed by guild ID
+func allGuildSettings() map[string]GuildSettings {
+	guildSettingsMu.Lock()
+	defer guildSettingsMu.Unlock()
+	all := make(map[string]GuildSettings)
+	for id, settings := range loadGuildSettingsFile().Guilds {
+		all[id] = *settings
+	}
+	return all
+}
+
+// updateGuildSettings applies update to a guild's settings and persists the result
+func updateGuildSettings(guildID string, update func(settings *GuildSettings)) error {
+	guildSettingsMu.Lock()
+	defer guildSettingsMu.Unlock()
+	file := loadGuildSettingsFile()
+	settings, ok := file.Guilds[guildID]
+	if !ok {
+		settings = &GuildSettings{}
+		file.Guilds[guildID] = settings
+	}
+	update(settings)
+	return writeDataFile(guildSettingsFileName, file)
+}
+
+// The update channel's guild, remembered once looked up
+var (
+	reportGuildMu     sync.Mutex
+	reportGuildCached string
+)
+
+// reportGuildID returns the guild the summaries are posted in: the configured guild, or the update channel's guild
+func reportGuildID(s *discordgo.Session) string {
+	if config.Discord.GuildID != "" || config.Discord.UpdateChannelID == "" {
+		return config.Discord.GuildID
+	}
+	if id := cachedReportGuildID(); id != "" {
+		return id
+	}
+	ch, err := s.Channel(config.Discord.UpdateChannelID)
+	if err != nil {
+		log.Println("Error fetching channel", config.Discord.UpdateChannelID, err)
+		return ""
+	}
+	reportGuildMu.Lock()
+	reportGuildCached = ch.GuildID
+	reportGuildMu.Unlock()
+	return ch.GuildID
+}
+
+// cachedReportGuildID returns the update channel's guild if reportGuildID has looked it up already
+func cachedReportGuildID() string {
+	reportGuildMu.Lock()
+	defer reportGuildMu.Unlock()
+	return reportGuildCached
+}
@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/olekukonko/tablewriter"
+)
+
+// The matches behind a /history message. Views live in memory so the Previous/Next buttons page through
+// the same list without querying FACEIT again; they expire with the interaction.
+type historyView struct {
+	Nickname string
+	Filter   string
+	Matches  []Stats
+	Created  time.Time
+}
+
+const (
+	historyDefaultCount = 20
+	historyMaxCount     = 100
+	historyPageSize     = 10
+	historyViewTTL      = 15 * time.Minute
+	// Discord allows 5 buttons per action row
+	buttonsPerRow = 5
+)
+
+var (
+	historyMu    sync.Mutex
+	historyViews = make(map[string]*historyView) // key: ID of the /history interaction
+)
+
+func storeHistoryView(key string, view *historyView) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	for k, v := range historyViews {
+		if time.Since(v.Created) > historyViewTTL {
+			delete(historyViews, k)
+		}
+	}
+	historyViews[key] = view
+}
+
+func getHistoryView(key string) (*historyView, bool) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	view, ok := historyViews[key]
+	if !ok || time.Since(view.Created) > historyViewTTL {
+		return nil, false
+	}
+	return view, true
+}
+
+// getRecentMatches returns a player's last count matches that pass filter, newest first. Like
+// getPlayerStatsRange it reads at most statsMaxOffset+statsPageSize matches back.
+func getRecentMatches(playerID string, count int, filter matchFilter) ([]Stats, error) {
+	var matches []Stats
+	for offset := 0; offset <= statsMaxOffset && len(matches) < count; offset += statsPageSize {
+		var list playerStatsList
+		if err := getFACEIT("/players/"+playerID+"/games/cs2/stats", map[string]interface{}{
+			"offset": offset,
+			"limit":  statsPageSize,
+		}, &list); err != nil {
+			return matches, err
+		}
+		for _, it := range list.Items {
+			if filter(it.Stats) && len(matches) < count {
+				matches = append(matches, it.Stats)
+			}
+		}
+		if len(list.Items) < statsPageSize {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// pages is the number of pages in the view, at least one
+func (view *historyView) pages() int {
+	if len(view.Matches) == 0 {
+		return 1
+	}
+	return (len(view.Matches) + historyPageSize - 1) / historyPageSize
+}
+
+// render returns the content and buttons of one page of the view
+func (view *historyView) render(key string, page int) (string, []discordgo.MessageComponent) {
+	title := fmt.Sprintf("**%s -- Last %d Matches**", view.Nickname, len(view.Matches))
+	if view.Filter != "" {
+		title += " (filter `" + view.Filter + "`)"
+	}
+	if len(view.Matches) == 0 {
+		return title + "\nNo matches found.", []discordgo.MessageComponent{}
+	}
+
+	first := page * historyPageSize
+	last := first + historyPageSize
+	if last > len(view.Matches) {
+		last = len(view.Matches)
+	}
+	loc := reportLocation()
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	table.Header([]string{"#", "DATE", "MAP", "SCORE", "RESULT", "K-D-A", "ADR"})
+	var details []discordgo.MessageComponent
+	for n, match := range view.Matches[first:last] {
+		result := "L"
+		if match.Result == 1 {
+			result = "W"
+		}
+		table.Append([]string{
+			strconv.Itoa(first + n + 1),
+			time.UnixMilli(match.MatchFinishedAt).In(loc).Format("01/02 15:04"),
+			match.Map,
+			strings.ReplaceAll(match.Score, " ", ""),
+			result,
+			fmt.Sprintf("%d-%d-%d", match.Kills, match.Deaths, match.Assists),
+			fmt.Sprintf("%.1f", match.ADR),
+		})
+		details = append(details, matchDetailsButton("#"+strconv.Itoa(first+n+1), match.MatchID))
+	}
+	table.Render()
+	content := fmt.Sprintf("%s — page %d/%d\n```%s```", title, page+1, view.pages(), builder.String())
+
+	var components []discordgo.MessageComponent
+	for len(details) > 0 {
+		n := buttonsPerRow
+		if len(details) < n {
+			n = len(details)
+		}
+		components = append(components, discordgo.ActionsRow{Components: details[:n]})
+		details = details[n:]
+	}
+	components = append(components, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Previous", Style: discordgo.PrimaryButton, CustomID: fmt.Sprintf("history:%s:%d", key, page-1), Disabled: page == 0},
+		discordgo.Button{Label: "Next", Style: discordgo.PrimaryButton, CustomID: fmt.Sprintf("history:%s:%d", key, page+1), Disabled: page >= view.pages()-1},
+	}})
+	return content, components
+}
+
+func handleHistory(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := optionsByName(i.ApplicationCommandData().Options)
+	nickname := strings.TrimSpace(opts["player"].StringValue())
+	count := historyDefaultCount
+	if opt, ok := opts["count"]; ok {
+		count = int(opt.IntValue())
+	}
+	filterExpr := ""
+	if opt, ok := opts["filter"]; ok {
+		filterExpr = strings.TrimSpace(opt.StringValue())
+	}
+	filter, err := compileFilter(filterExpr)
+	if err != nil {
+		respondEphemeral(s, i, "Invalid filter: "+err.Error())
+		return
+	}
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	go func() {
+		edit := &discordgo.WebhookEdit{}
+		profile, err := getPlayerByNickname(nickname)
+		if err != nil {
+			content := "Player not found on FACEIT: " + nickname
+			edit.Content = &content
+		} else if matches, err := getRecentMatches(profile.PlayerID, count, filter); err != nil {
+			log.Printf("Error getting match history for %s: %v", nickname, err)
+			content := "Could not load the match history for " + profile.Nickname + "."
+			edit.Content = &content
+		} else {
+			view := &historyView{Nickname: profile.Nickname, Filter: filterExpr, Matches: matches, Created: time.Now()}
+			storeHistoryView(i.ID, view)
+			content, components := view.render(i.ID, 0)
+			edit.Content = &content
+			edit.Components = &components
+		}
+		if _, err := interactionResponseEdit(s, i.Interaction, edit); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+	}()
+}
+
+// handleHistoryButton handles the Previous/Next buttons of a /history message
+func handleHistoryButton(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if len(args) != 2 {
+		respondEphemeral(s, i, "This button is not valid, run `/history` again.")
+		return
+	}
+	view, ok := getHistoryView(args[0])
+	if !ok {
+		respondEphemeral(s, i, "This history has expired, run `/history` again.")
+		return
+	}
+	page, err := strconv.Atoi(args[1])
+	if err != nil || page < 0 || page >= view.pages() {
+		respondEphemeral(s, i, "That page doesn't exist, run `/history` again.")
+		return
+	}
+	content, components := view.render(args[0], page)
+	if err := interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: content, Components: components},
+	}); err != nil {
+		log.Printf("failed to update history message: %v", err)
+	}
+}
@@ -0,0 +1,381 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/olekukonko/tablewriter"
+)
+
+// The FACEIT hub a guild follows. The standings message is only posted when a channel is set.
+type HubSettings struct {
+	HubID              string `json:"hub_id,omitempty"`
+	Name               string `json:"name,omitempty"`
+	StandingsChannelID string `json:"standings_channel_id,omitempty"`
+}
+
+// One row of a FACEIT hub leaderboard (the Ranking definition in the spec)
+type hubRanking struct {
+	Position      int     `json:"position"`
+	Points        int     `json:"points"`
+	Played        int     `json:"played"`
+	Won           int     `json:"won"`
+	Lost          int     `json:"lost"`
+	WinRate       float64 `json:"win_rate"`
+	CurrentStreak int     `json:"current_streak"`
+	Player        struct {
+		UserID   string `json:"user_id"`
+		Nickname string `json:"nickname"`
+	} `json:"player"`
+}
+
+// Wrapper for the FACEIT /leaderboards/hubs/{hub_id}/general and .../seasons/{season} responses
+type hubLeaderboard struct {
+	Leaderboard struct {
+		LeaderboardName string `json:"leaderboard_name"`
+		Season          int    `json:"season"`
+	} `json:"leaderboard"`
+	Items []hubRanking `json:"items"`
+}
+
+// Wrapper for the FACEIT /hubs/{hub_id}/stats response. Stat names are the ones shown on FACEIT.
+type hubStatsList struct {
+	Players []struct {
+		PlayerID string                 `json:"player_id"`
+		Nickname string                 `json:"nickname"`
+		Stats    map[string]interface{} `json:"stats"`
+	} `json:"players"`
+}
+
+const (
+	hubLeaderboardSize   = 20
+	hubRecentMatches     = 10
+	maxHubStatsPlayers   = 1000
+	hubStandingsMarker   = "**Hub Standings**: "
+	hubNotConfiguredText = "No FACEIT hub configured for this server. Use `/hub set`."
+)
+
+// Columns of the /hub stats table, keyed by the stat name in the hub stats response
+var hubStatColumns = []struct{ Header, Stat string }{
+	{"MATCHES", "Matches"},
+	{"WR%", "Win Rate %"},
+	{"KD", "Average K/D Ratio"},
+	{"HS%", "Average Headshots %"},
+}
+
+// parseHubID accepts a bare hub ID or a FACEIT hub URL such as https://www.faceit.com/en/hub/<id>/<name>
+func parseHubID(input string) string {
+	input = strings.TrimSpace(input)
+	parts := strings.Split(input, "/")
+	for i, part := range parts {
+		if part == "hub" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return input
+}
+
+func getHubName(hubID string) (string, error) {
+	var hub struct {
+		Name string `json:"name"`
+	}
+	err := getFACEIT("/hubs/"+url.PathEscape(hubID), nil, &hub)
+	return hub.Name, err
+}
+
+// getHubLeaderboard returns the top 100 of a season's leaderboard, or of the general leaderboard for season 0
+func getHubLeaderboard(hubID string, season int64) (hubLeaderboard, error) {
+	endpoint := "/leaderboards/hubs/" + url.PathEscape(hubID) + "/general"
+	if season > 0 {
+		endpoint = "/leaderboards/hubs/" + url.PathEscape(hubID) + "/seasons/" + strconv.FormatInt(season, 10)
+	}
+	var leaderboard hubLeaderboard
+	err := getFACEIT(endpoint, map[string]interface{}{
+		"offset": 0,
+		"limit":  100,
+	}, &leaderboard)
+	return leaderboard, err
+}
+
+func getHubMatches(hubID string, limit int) ([]FACEITMatch, error) {
+	var page matchList
+	err := getFACEIT("/hubs/"+url.PathEscape(hubID)+"/matches", map[string]interface{}{
+		"type":   "past",
+		"offset": 0,
+		"limit":  limit,
+	}, &page)
+	return page.Items, err
+}
+
+// getHubStats pages through the hub's player stats until every tracked player has been seen
+func getHubStats(hubID string, tracked map[string]bool) (hubStatsList, error) {
+	var stats hubStatsList
+	found := 0
+	for offset := 0; offset < maxHubStatsPlayers && found < len(tracked); offset += 100 {
+		var page hubStatsList
+		if err := getFACEIT("/hubs/"+url.PathEscape(hubID)+"/stats", map[string]interface{}{
+			"offset": offset,
+			"limit":  100,
+		}, &page); err != nil {
+			return stats, err
+		}
+		for _, player := range page.Players {
+			if tracked[strings.ToLower(player.Nickname)] {
+				stats.Players = append(stats.Players, player)
+				found++
+			}
+		}
+		if len(page.Players) < 100 {
+			break
+		}
+	}
+	return stats, nil
+}
+
+// trackedNicknames returns the lower-cased names of the tracked players, used to highlight them in hub tables
+func trackedNicknames() map[string]bool {
+	tracked := make(map[string]bool)
+	for _, name := range loadPlayerJSON().PlayerName {
+		tracked[strings.ToLower(name)] = true
+	}
+	return tracked
+}
+
+// highlight marks tracked players the same way the league report marks our team
+func highlight(nickname string, tracked map[string]bool) string {
+	if tracked[strings.ToLower(nickname)] {
+		return "» " + nickname
+	}
+	return nickname
+}
+
+// renderHubLeaderboard renders the top of the leaderboard, followed by any tracked players ranked below it
+func renderHubLeaderboard(leaderboard hubLeaderboard, tracked map[string]bool) string {
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	table.Header([]string{"#", "NAME", "PTS", "W-L", "WR%", "STREAK"})
+	for i, rank := range leaderboard.Items {
+		if i >= hubLeaderboardSize && !tracked[strings.ToLower(rank.Player.Nickname)] {
+			continue
+		}
+		table.Append([]string{
+			strconv.Itoa(rank.Position),
+			highlight(rank.Player.Nickname, tracked),
+			strconv.Itoa(rank.Points),
+			fmt.Sprintf("%d-%d", rank.Won, rank.Lost),
+			fmt.Sprintf("%.0f", rank.WinRate*100),
+			strconv.Itoa(rank.CurrentStreak),
+		})
+	}
+	table.Render()
+	return builder.String()
+}
+
+// renderHubMatches lists recent hub matches with the tracked players that played in each
+func renderHubMatches(matches []FACEITMatch, tracked map[string]bool) string {
+	loc := reportLocation()
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	table.Header([]string{"DATE", "MAP", "SCORE", "WINNER", "TRACKED"})
+	for _, match := range matches {
+		var ours []string
+		for _, faction := range match.Teams {
+			for _, member := range faction.Roster {
+				if tracked[strings.ToLower(member.Nickname)] {
+					ours = append(ours, member.Nickname)
+				}
+			}
+		}
+		mapName := match.mapName()
+		if mapName == "" {
+			mapName = "-"
+		}
+		table.Append([]string{
+			time.Unix(match.FinishedAt, 0).In(loc).Format("01/02 15:04"),
+			mapName,
+			fmt.Sprintf("%d-%d", match.Results.Score["faction1"], match.Results.Score["faction2"]),
+			match.Teams[match.Results.Winner].Name,
+			strings.Join(ours, ", "),
+		})
+	}
+	table.Render()
+	return builder.String()
+}
+
+func renderHubStats(stats hubStatsList) string {
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	header := []string{"NAME"}
+	for _, column := range hubStatColumns {
+		header = append(header, column.Header)
+	}
+	table.Header(header)
+	for _, player := range stats.Players {
+		columns := []string{player.Nickname}
+		for _, column := range hubStatColumns {
+			value, ok := player.Stats[column.Stat]
+			if !ok {
+				columns = append(columns, "-")
+				continue
+			}
+			columns = append(columns, fmt.Sprint(value))
+		}
+		table.Append(columns)
+	}
+	table.Render()
+	return builder.String()
+}
+
+// title is the hub's stored name, or its ID if the name is not known
+func (hub HubSettings) title() string {
+	if hub.Name != "" {
+		return hub.Name
+	}
+	return hub.HubID
+}
+
+// getHubStandings builds the standings message. The marker identifies the message for later edits.
+func getHubStandings(hub HubSettings) (marker, msg string, err error) {
+	leaderboard, err := getHubLeaderboard(hub.HubID, 0)
+	if err != nil {
+		return "", "", fmt.Errorf("hub %s leaderboard: %v", hub.HubID, err)
+	}
+	marker = hubStandingsMarker + hub.title()
+	msg = marker + "\n```" + renderHubLeaderboard(leaderboard, trackedNicknames()) + "```"
+	return marker, msg, nil
+}
+
+// UpdateHubStandings posts or edits the standings message of every guild that has a standings channel set
+func UpdateHubStandings(s *discordgo.Session) {
+	for id, settings := range allGuildSettings() {
+		hub := settings.Hub
+		if hub.HubID == "" || hub.StandingsChannelID == "" {
+			continue
+		}
+		marker, msg, err := getHubStandings(hub)
+		if err != nil {
+			log.Printf("Hub standings for guild %s failed: %v", id, err)
+			continue
+		}
+		updateChannelMessage(s, hub.StandingsChannelID, truncateMessage(msg), marker)
+	}
+}
+
+// hubReport renders one of the read-only /hub subcommands
+func hubReport(hub HubSettings, subcommand string, opts map[string]*discordgo.ApplicationCommandInteractionDataOption) string {
+	tracked := trackedNicknames()
+	switch subcommand {
+	case "leaderboard":
+		var season int64
+		if opt, ok := opts["season"]; ok {
+			season = opt.IntValue()
+		}
+		leaderboard, err := getHubLeaderboard(hub.HubID, season)
+		if err != nil {
+			return "Could not load the hub leaderboard: " + err.Error()
+		}
+		title := "**" + hub.title() + " -- Leaderboard**"
+		if season > 0 {
+			title = fmt.Sprintf("**%s -- Season %d Leaderboard**", hub.title(), season)
+		}
+		if len(leaderboard.Items) == 0 {
+			return title + "\nNo ranked players yet."
+		}
+		return title + "\n```" + renderHubLeaderboard(leaderboard, tracked) + "```"
+	case "recent-matches":
+		matches, err := getHubMatches(hub.HubID, hubRecentMatches)
+		if err != nil {
+			return "Could not load the hub matches: " + err.Error()
+		}
+		if len(matches) == 0 {
+			return "No finished matches in " + hub.title() + " yet."
+		}
+		return "**" + hub.title() + " -- Recent Matches**\n```" + renderHubMatches(matches, tracked) + "```"
+	case "stats":
+		stats, err := getHubStats(hub.HubID, tracked)
+		if err != nil {
+			return "Could not load the hub stats: " + err.Error()
+		}
+		if len(stats.Players) == 0 {
+			return "None of the tracked players have played in " + hub.title() + "."
+		}
+		return "**" + hub.title() + " -- Tracked Player Stats**\n```" + renderHubStats(stats) + "```"
+	}
+	return ""
+}
+
+// setHub handles /hub set and /hub clear and returns the reply
+func setHub(guildID, subcommand string, opts map[string]*discordgo.ApplicationCommandInteractionDataOption) string {
+	hub := HubSettings{}
+	if subcommand == "set" {
+		hub.HubID = parseHubID(opts["hub"].StringValue())
+		name, err := getHubName(hub.HubID)
+		if err != nil {
+			return "Could not find FACEIT hub `" + hub.HubID + "`."
+		}
+		hub.Name = name
+		if opt, ok := opts["standings_channel"]; ok {
+			hub.StandingsChannelID = opt.ChannelValue(nil).ID
+		}
+	}
+	if err := updateGuildSettings(guildID, func(settings *GuildSettings) { settings.Hub = hub }); err != nil {
+		log.Println("Error saving guild settings", err)
+		return "Could not save the hub settings, try again later."
+	}
+	content := "FACEIT hub removed."
+	if hub.HubID != "" {
+		content = "FACEIT hub set to **" + hub.Name + "**."
+		if hub.StandingsChannelID != "" {
+			content += " Standings are posted hourly in <#" + hub.StandingsChannelID + ">."
+		}
+	}
+	return content
+}
+
+func handleHub(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionsByName(sub.Options)
+	switch sub.Name {
+	case "set", "clear":
+		if !hasManageGuildPermission(i) {
+			respondEphemeral(s, i, "You do not have permission to use this command.")
+			return
+		}
+		// Looking the hub up on FACEIT can take longer than Discord waits for an answer
+		interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		})
+		go func() {
+			content := setHub(i.GuildID, sub.Name, opts)
+			if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
+				Content: &content,
+			}); err != nil {
+				log.Printf("failed to edit response: %v", err)
+			}
+		}()
+	default:
+		hub := getGuildSettings(i.GuildID).Hub
+		if hub.HubID == "" {
+			respondEphemeral(s, i, hubNotConfiguredText)
+			return
+		}
+		interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		})
+		go func() {
+			content := truncateMessage(hubReport(hub, sub.Name, opts))
+			if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
+				Content: &content,
+			}); err != nil {
+				log.Printf("failed to edit response: %v", err)
+			}
+		}()
+	}
+}
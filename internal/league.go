@@ -0,0 +1,365 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/olekukonko/tablewriter"
+)
+
+// The league/championship the team plays in. The league report is built from the championship's matches,
+// so it covers exactly the games the weekly pug summaries filter out.
+type LeagueConfig struct {
+	ChampionshipID string `json:"championship_id"`
+	LeagueID       string `json:"league_id,omitempty"`
+	SeasonID       string `json:"season_id,omitempty"`
+	Team           string `json:"team,omitempty"` // our team's name in the championship, defaults to TEAM_NAME
+}
+
+// Subset of the FACEIT /leagues/{league_id}/seasons/{season_id}/players/{player_id} response
+type PlayerInLeague struct {
+	DivisionName string `json:"division_name"`
+	DivisionTier string `json:"division_tier"`
+	Points       int    `json:"points"`
+	Position     int    `json:"position"`
+}
+
+// A team's record in the championship
+type leagueStanding struct {
+	Team   string
+	Wins   int
+	Losses int
+}
+
+const (
+	leagueConfigFile = "league.json"
+	// Championship match lists are paged by 100, this caps how far back we read
+	maxChampionshipMatches = 1000
+	leagueRecentResults    = 10
+)
+
+var leagueMu sync.Mutex
+
+func loadLeagueConfig() LeagueConfig {
+	leagueMu.Lock()
+	defer leagueMu.Unlock()
+	var cfg LeagueConfig
+	if err := readDataFile(leagueConfigFile, &cfg); err != nil {
+		log.Println("Error reading", leagueConfigFile, err)
+	}
+	return cfg
+}
+
+func saveLeagueConfig(cfg LeagueConfig) error {
+	leagueMu.Lock()
+	defer leagueMu.Unlock()
+	return writeDataFile(leagueConfigFile, cfg)
+}
+
+// team returns the configured team name, falling back to TEAM_NAME
+func (cfg LeagueConfig) team() string {
+	if cfg.Team != "" {
+		return cfg.Team
+	}
+	return config.TeamName
+}
+
+func getChampionshipName(championshipID string) (string, error) {
+	var championship struct {
+		Name string `json:"name"`
+	}
+	err := getFACEIT("/championships/"+url.PathEscape(championshipID), nil, &championship)
+	return championship.Name, err
+}
+
+// getChampionshipMatches returns the championship's finished matches
+func getChampionshipMatches(championshipID string) ([]FACEITMatch, error) {
+	var matches []FACEITMatch
+	for offset := 0; offset < maxChampionshipMatches; offset += 100 {
+		var page matchList
+		if err := getFACEIT("/championships/"+url.PathEscape(championshipID)+"/matches", map[string]interface{}{
+			"type":   "past",
+			"offset": offset,
+			"limit":  100,
+		}, &page); err != nil {
+			return matches, err
+		}
+		matches = append(matches, page.Items...)
+		if len(page.Items) < 100 {
+			break
+		}
+	}
+	return matches, nil
+}
+
+func getPlayerInLeague(cfg LeagueConfig, playerID string) (PlayerInLeague, error) {
+	var info PlayerInLeague
+	err := getFACEIT("/leagues/"+url.PathEscape(cfg.LeagueID)+"/seasons/"+url.PathEscape(cfg.SeasonID)+"/players/"+url.PathEscape(playerID), nil, &info)
+	return info, err
+}
+
+// factionFor returns the faction key ("faction1"/"faction2") our team played as, or "" if it did not play
+func factionFor(match FACEITMatch, team string) string {
+	for key, faction := range match.Teams {
+		if strings.EqualFold(faction.Name, team) {
+			return key
+		}
+	}
+	return ""
+}
+
+func opponentFaction(match FACEITMatch, ours string) FACEITFaction {
+	for key, faction := range match.Teams {
+		if key != ours {
+			return faction
+		}
+	}
+	return FACEITFaction{}
+}
+
+// leagueStandings tallies wins and losses per team from the finished matches
+func leagueStandings(matches []FACEITMatch) []leagueStanding {
+	byTeam := make(map[string]*leagueStanding)
+	for _, match := range matches {
+		if match.Results.Winner == "" {
+			continue
+		}
+		for key, faction := range match.Teams {
+			st, ok := byTeam[faction.Name]
+			if !ok {
+				st = &leagueStanding{Team: faction.Name}
+				byTeam[faction.Name] = st
+			}
+			if key == match.Results.Winner {
+				st.Wins++
+			} else {
+				st.Losses++
+			}
+		}
+	}
+	standings := make([]leagueStanding, 0, len(byTeam))
+	for _, st := range byTeam {
+		standings = append(standings, *st)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Wins != standings[j].Wins {
+			return standings[i].Wins > standings[j].Wins
+		}
+		if standings[i].Losses != standings[j].Losses {
+			return standings[i].Losses < standings[j].Losses
+		}
+		return strings.ToLower(standings[i].Team) < strings.ToLower(standings[j].Team)
+	})
+	return standings
+}
+
+// leagueRoster returns everyone who played for our team in the championship
+func leagueRoster(matches []FACEITMatch, team string) []FACEITPlayers {
+	seen := make(map[string]bool)
+	var players []FACEITPlayers
+	for _, match := range matches {
+		ours := factionFor(match, team)
+		if ours == "" {
+			continue
+		}
+		for _, member := range match.Teams[ours].Roster {
+			if !seen[member.PlayerID] {
+				seen[member.PlayerID] = true
+				players = append(players, FACEITPlayers{PlayerName: member.Nickname, PlayerID: member.PlayerID})
+			}
+		}
+	}
+	return players
+}
+
+func renderLeagueStandings(standings []leagueStanding, team string) string {
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	table.Header([]string{"#", "TEAM", "W-L"})
+	for i, st := range standings {
+		name := st.Team
+		if strings.EqualFold(name, team) {
+			name = "» " + name
+		}
+		table.Append([]string{strconv.Itoa(i + 1), name, fmt.Sprintf("%d-%d", st.Wins, st.Losses)})
+	}
+	table.Render()
+	return builder.String()
+}
+
+func renderLeagueResults(matches []FACEITMatch, team string) string {
+	var ours []FACEITMatch
+	for _, match := range matches {
+		if factionFor(match, team) != "" {
+			ours = append(ours, match)
+		}
+	}
+	sort.Slice(ours, func(i, j int) bool { return ours[i].FinishedAt > ours[j].FinishedAt })
+	if len(ours) > leagueRecentResults {
+		ours = ours[:leagueRecentResults]
+	}
+	loc := reportLocation()
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	table.Header([]string{"DATE", "OPPONENT", "SCORE", "RESULT"})
+	for _, match := range ours {
+		side := factionFor(match, team)
+		opponent := opponentFaction(match, side)
+		opponentSide := "faction1"
+		if side == "faction1" {
+			opponentSide = "faction2"
+		}
+		result := "L"
+		if match.Results.Winner == side {
+			result = "W"
+		}
+		table.Append([]string{
+			time.Unix(match.FinishedAt, 0).In(loc).Format("01/02"),
+			opponent.Name,
+			fmt.Sprintf("%d-%d", match.Results.Score[side], match.Results.Score[opponentSide]),
+			result,
+		})
+	}
+	table.Render()
+	return builder.String()
+}
+
+// renderLeaguePlayers renders the per-player league stats, with division/points when a league season is set
+func renderLeaguePlayers(cfg LeagueConfig, rows []*MatchHistory) string {
+	withLeague := cfg.LeagueID != "" && cfg.SeasonID != ""
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	header := []string{"NAME", "MATCHES", "W-L", "KD", "HS%"}
+	if withLeague {
+		header = append(header, "DIVISION", "PTS", "POS")
+	}
+	table.Header(header)
+	for _, mh := range rows {
+		columns := matchHistoryColumns(mh)
+		if withLeague {
+			if info, err := getPlayerInLeague(cfg, mh.PlayerID); err == nil {
+				columns = append(columns, strings.TrimSpace(info.DivisionName+" "+info.DivisionTier), strconv.Itoa(info.Points), strconv.Itoa(info.Position))
+			} else {
+				columns = append(columns, "-", "-", "-")
+			}
+		}
+		table.Append(columns)
+	}
+	table.Render()
+	return builder.String()
+}
+
+// getLeagueReport builds the league summary message. The marker identifies the message for later edits.
+func getLeagueReport(cfg LeagueConfig) (marker, msg string, err error) {
+	name, err := getChampionshipName(cfg.ChampionshipID)
+	if err != nil {
+		return "", "", fmt.Errorf("championship %s: %v", cfg.ChampionshipID, err)
+	}
+	matches, err := getChampionshipMatches(cfg.ChampionshipID)
+	if err != nil {
+		return "", "", fmt.Errorf("championship %s matches: %v", cfg.ChampionshipID, err)
+	}
+	team := cfg.team()
+
+	// Per-player stats cover the championship's whole span, limited to its matches
+	players := leagueRoster(matches, team)
+	if len(players) == 0 {
+		players = getPlayerIDs()
+	}
+	start := time.Now().AddDate(0, -6, 0)
+	for _, match := range matches {
+		if match.StartedAt > 0 && match.StartedAt < start.Unix() {
+			start = time.Unix(match.StartedAt, 0)
+		}
+	}
+	inChampionship := func(s Stats) bool { return s.CompetitionID == cfg.ChampionshipID }
+	rows := aggregateMatchHistory(players, inChampionship, ToUnixMillis(start), ToUnixMillis(time.Now()))
+
+	marker = "**League -- Match History**: " + name
+	msg = marker + "\n\n" +
+		"**Standings**\n```" + renderLeagueStandings(leagueStandings(matches), team) + "```\n"
+	if team != "" {
+		msg += "**" + team + " results**\n```" + renderLeagueResults(matches, team) + "```\n"
+	}
+	msg += "**Players**\n```" + renderLeaguePlayers(cfg, rows) + "```"
+	return marker, msg, nil
+}
+
+// UpdateLeagueReport posts or edits the league summary when a championship is configured
+func UpdateLeagueReport(s *discordgo.Session) {
+	cfg := loadLeagueConfig()
+	if cfg.ChampionshipID == "" {
+		return
+	}
+	marker, msg, err := getLeagueReport(cfg)
+	if err != nil {
+		log.Println("League report failed:", err)
+		return
+	}
+	UpdateMessage(s, truncateMessage(msg), marker)
+}
+
+func handleLeague(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionsByName(sub.Options)
+	switch sub.Name {
+	case "set":
+		cfg := LeagueConfig{ChampionshipID: strings.TrimSpace(opts["championship"].StringValue())}
+		if opt, ok := opts["league_id"]; ok {
+			cfg.LeagueID = strings.TrimSpace(opt.StringValue())
+		}
+		if opt, ok := opts["season_id"]; ok {
+			cfg.SeasonID = strings.TrimSpace(opt.StringValue())
+		}
+		if opt, ok := opts["team"]; ok {
+			cfg.Team = strings.TrimSpace(opt.StringValue())
+		}
+		if (cfg.LeagueID == "") != (cfg.SeasonID == "") {
+			respondEphemeral(s, i, "`league_id` and `season_id` must be set together.")
+			return
+		}
+		if err := saveLeagueConfig(cfg); err != nil {
+			log.Println("Error saving league config", err)
+			respondEphemeral(s, i, "Could not save the league settings, try again later.")
+			return
+		}
+		respondEphemeral(s, i, "League report set to championship `"+cfg.ChampionshipID+"`. It is posted on the next refresh.")
+	case "clear":
+		if err := saveLeagueConfig(LeagueConfig{}); err != nil {
+			log.Println("Error saving league config", err)
+		}
+		respondEphemeral(s, i, "League report disabled.")
+	case "show":
+		interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		})
+		go func() {
+			content := "No championship configured. Use `/league set`."
+			if cfg := loadLeagueConfig(); cfg.ChampionshipID != "" {
+				if _, msg, err := getLeagueReport(cfg); err != nil {
+					content = "League report failed: " + err.Error()
+				} else {
+					content = truncateMessage(msg)
+				}
+			}
+			if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
+				Content: &content,
+			}); err != nil {
+				log.Printf("failed to edit response: %v", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,299 @@
+package internal
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A Discord account linked to a FACEIT account. Links start out pending with a one-time code and are only
+// trusted once the FACEIT profile proves ownership (Discord connection or the code placed on the profile).
+type AccountLink struct {
+	DiscordID   string `json:"discord_id"`
+	PlayerID    string `json:"player_id"`
+	Nickname    string `json:"nickname"`
+	Code        string `json:"code,omitempty"`
+	Verified    bool   `json:"verified"`
+	RequestedAt int64  `json:"requested_at"`
+	VerifiedAt  int64  `json:"verified_at,omitempty"`
+}
+
+// This struct is the layout of data/linked_accounts.json
+type accountLinks struct {
+	Links []AccountLink `json:"links"`
+}
+
+const (
+	linksFile = "linked_accounts.json"
+	// Interaction tokens expire after 15 minutes, so polling stops just before that
+	linkPollInterval = 30 * time.Second
+	linkPollTimeout  = 14 * time.Minute
+	linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	linksMu     sync.Mutex
+	linkPollers sync.Map // key: Discord user ID, value: struct{} while a poller is running
+)
+
+func loadLinks() accountLinks {
+	links := accountLinks{Links: []AccountLink{}}
+	if err := readDataFile(linksFile, &links); err != nil {
+		log.Println("Error reading", linksFile, err)
+	}
+	return links
+}
+
+func saveLinks(links accountLinks) error {
+	return writeDataFile(linksFile, links)
+}
+
+// getLink returns the link (pending or verified) for a Discord user
+func getLink(discordID string) (AccountLink, bool) {
+	linksMu.Lock()
+	defer linksMu.Unlock()
+	for _, link := range loadLinks().Links {
+		if link.DiscordID == discordID {
+			return link, true
+		}
+	}
+	return AccountLink{}, false
+}
+
+// getVerifiedLinks returns every verified link
+func getVerifiedLinks() []AccountLink {
+	linksMu.Lock()
+	defer linksMu.Unlock()
+	var verified []AccountLink
+	for _, link := range loadLinks().Links {
+		if link.Verified {
+			verified = append(verified, link)
+		}
+	}
+	return verified
+}
+
+// putLink inserts or replaces the link for link.DiscordID
+func putLink(link AccountLink) error {
+	linksMu.Lock()
+	defer linksMu.Unlock()
+	links := loadLinks()
+	for i := range links.Links {
+		if links.Links[i].DiscordID == link.DiscordID {
+			links.Links[i] = link
+			return saveLinks(links)
+		}
+	}
+	links.Links = append(links.Links, link)
+	return saveLinks(links)
+}
+
+// removeLink deletes the link for a Discord user and reports whether one existed
+func removeLink(discordID string) (bool, error) {
+	linksMu.Lock()
+	defer linksMu.Unlock()
+	links := loadLinks()
+	for i, link := range links.Links {
+		if link.DiscordID == discordID {
+			links.Links = append(links.Links[:i], links.Links[i+1:]...)
+			return true, saveLinks(links)
+		}
+	}
+	return false, nil
+}
+
+// verifiedOwner returns the Discord user already holding a verified link to playerID, if any
+func verifiedOwner(playerID string) (string, bool) {
+	linksMu.Lock()
+	defer linksMu.Unlock()
+	for _, link := range loadLinks().Links {
+		if link.Verified && link.PlayerID == playerID {
+			return link.DiscordID, true
+		}
+	}
+	return "", false
+}
+
+func newLinkCode() string {
+	code := make([]byte, 6)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(linkCodeAlphabet))))
+		if err != nil {
+			log.Fatal("Error generating link code: ", err)
+		}
+		code[i] = linkCodeAlphabet[n.Int64()]
+	}
+	return "LG-" + string(code)
+}
+
+// profileProvesOwnership checks the FACEIT profile for the Discord connection or the pending link code
+func profileProvesOwnership(profile FACEITPlayerProfile, link AccountLink, user *discordgo.User) bool {
+	for platform, value := range profile.Platforms {
+		if strings.EqualFold(platform, "discord") && value != "" &&
+			(value == user.ID || strings.EqualFold(value, user.Username) || strings.EqualFold(value, user.String())) {
+			return true
+		}
+	}
+	if link.Code == "" {
+		return false
+	}
+	fields := []string{profile.SteamNickname}
+	for _, value := range profile.Platforms {
+		fields = append(fields, value)
+	}
+	for _, value := range profile.Settings {
+		fields = append(fields, value)
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToUpper(field), link.Code) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyLink re-reads the FACEIT profile and marks the link verified when ownership is proven
+func verifyLink(link AccountLink, user *discordgo.User) (AccountLink, error) {
+	profile, err := getPlayerByID(link.PlayerID)
+	if err != nil {
+		return link, err
+	}
+	if !profileProvesOwnership(profile, link, user) {
+		return link, nil
+	}
+	if owner, ok := verifiedOwner(link.PlayerID); ok && owner != link.DiscordID {
+		return link, fmt.Errorf("%s is already linked to another Discord account", link.Nickname)
+	}
+	link.Verified = true
+	link.VerifiedAt = time.Now().Unix()
+	link.Code = ""
+	link.Nickname = profile.Nickname
+	return link, putLink(link)
+}
+
+// LinkAccount starts (or re-checks) linking user to a FACEIT nickname. The returned bool is true when
+// the link still needs verification.
+func LinkAccount(user *discordgo.User, nickname string) (string, bool) {
+	profile, err := getPlayerByNickname(nickname)
+	if err != nil || profile.PlayerID == "" {
+		return "Player not found on FACEIT: " + nickname, false
+	}
+	if owner, ok := verifiedOwner(profile.PlayerID); ok {
+		if owner == user.ID {
+			return "Already linked and verified: " + profile.Nickname, false
+		}
+		return profile.Nickname + " is already linked to another Discord account.", false
+	}
+
+	link, ok := getLink(user.ID)
+	if !ok || link.PlayerID != profile.PlayerID || link.Verified {
+		link = AccountLink{
+			DiscordID:   user.ID,
+			PlayerID:    profile.PlayerID,
+			Nickname:    profile.Nickname,
+			Code:        newLinkCode(),
+			RequestedAt: time.Now().Unix(),
+		}
+		if err := putLink(link); err != nil {
+			log.Println("Error saving link", err)
+			return "Could not save the link request, try again later.", false
+		}
+	}
+
+	if profileProvesOwnership(profile, link, user) {
+		if link, err = verifyLink(link, user); err != nil {
+			return "Verification failed: " + err.Error(), false
+		}
+		return "Linked and verified: " + link.Nickname, false
+	}
+	return linkInstructions(link), true
+}
+
+func linkInstructions(link AccountLink) string {
+	return fmt.Sprintf("Link to **%s** is pending verification.\n"+
+		"Either connect this Discord account on your FACEIT profile, or temporarily add the code `%s` to your Steam name "+
+		"(FACEIT shows it as your Steam nickname).\n"+
+		"I will check every %s for the next %s; run `/link` again to re-check later.",
+		link.Nickname, link.Code, linkPollInterval, linkPollTimeout)
+}
+
+// pollLinkVerification keeps re-checking a pending link and edits the interaction response with the outcome
+func pollLinkVerification(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User) {
+	if _, running := linkPollers.LoadOrStore(user.ID, struct{}{}); running {
+		return
+	}
+	defer linkPollers.Delete(user.ID)
+
+	deadline := time.Now().Add(linkPollTimeout)
+	ticker := time.NewTicker(linkPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		link, ok := getLink(user.ID)
+		if !ok || link.Verified {
+			return
+		}
+		content := ""
+		if time.Now().After(deadline) {
+			content = "Verification for **" + link.Nickname + "** timed out. Your code `" + link.Code +
+				"` stays valid, run `/link` again once it is on your profile."
+		} else if verified, err := verifyLink(link, user); err != nil {
+			log.Printf("Link verification for %s failed: %v", user.ID, err)
+			if !transientFACEITError(err) {
+				content = "Verification for **" + link.Nickname + "** stopped: " + err.Error()
+			}
+		} else if verified.Verified {
+			content = "Linked and verified: " + verified.Nickname
+		}
+		if content == "" {
+			continue
+		}
+		if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+		return
+	}
+}
+
+func handleLink(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := interactionUser(i)
+	if user == nil {
+		return
+	}
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	go func() {
+		content, pending := LinkAccount(user, i.ApplicationCommandData().Options[0].StringValue())
+		if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		}); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+		if pending {
+			pollLinkVerification(s, i, user)
+		}
+	}()
+}
+
+func handleUnlink(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := interactionUser(i)
+	if user == nil {
+		return
+	}
+	content := "You do not have a linked FACEIT account."
+	removed, err := removeLink(user.ID)
+	if err != nil {
+		log.Println("Error removing link", err)
+		content = "Could not remove the link, try again later."
+	} else if removed {
+		content = "FACEIT account unlinked."
+	}
+	respondEphemeral(s, i, content)
+}
@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Subset of the FACEIT /matches/{match_id}/stats response. Each round is one map of the series.
+type matchStats struct {
+	Rounds []struct {
+		MatchRound int `json:"match_round,string"`
+		RoundStats struct {
+			Map    string `json:"Map"`
+			Score  string `json:"Score"`
+			Winner string `json:"Winner"`
+		} `json:"round_stats"`
+		Teams []matchStatsTeam `json:"teams"`
+	} `json:"rounds"`
+}
+
+type matchStatsTeam struct {
+	TeamID    string `json:"team_id"`
+	TeamStats struct {
+		Team            string `json:"Team"`
+		FirstHalfScore  int    `json:"First Half Score,string"`
+		SecondHalfScore int    `json:"Second Half Score,string"`
+		OvertimeScore   int    `json:"Overtime score,string"`
+		FinalScore      int    `json:"Final Score,string"`
+	} `json:"team_stats"`
+	Players []struct {
+		PlayerID    string `json:"player_id"`
+		Nickname    string `json:"nickname"`
+		PlayerStats struct {
+			Kills               int     `json:"Kills,string"`
+			Deaths              int     `json:"Deaths,string"`
+			Assists             int     `json:"Assists,string"`
+			KDRatio             float64 `json:"K/D Ratio,string"`
+			ADR                 float64 `json:"ADR,string"`
+			HeadshotsPercentage float64 `json:"Headshots %,string"`
+			MVPs                int     `json:"MVPs,string"`
+		} `json:"player_stats"`
+	} `json:"players"`
+}
+
+// parseMatchID accepts a bare match ID or a FACEIT room URL such as https://www.faceit.com/en/cs2/room/<id>/scoreboard
+func parseMatchID(input string) string {
+	input = strings.TrimSpace(input)
+	parts := strings.Split(input, "/")
+	for i, part := range parts {
+		if part == "room" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return input
+}
+
+func getMatch(matchID string) (FACEITMatch, error) {
+	var match FACEITMatch
+	err := getFACEIT("/matches/"+url.PathEscape(matchID), nil, &match)
+	return match, err
+}
+
+func getMatchStats(matchID string) (matchStats, error) {
+	var stats matchStats
+	err := getFACEIT("/matches/"+url.PathEscape(matchID)+"/stats", nil, &stats)
+	return stats, err
+}
+
+// renderScoreboard renders one team's scoreboard, best fragger first
+func renderScoreboard(team matchStatsTeam, tracked map[string]bool) string {
+	players := team.Players
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].PlayerStats.Kills > players[j].PlayerStats.Kills
+	})
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	table.Header([]string{"NAME", "K", "D", "A", "KD", "ADR", "HS%", "MVP"})
+	for _, player := range players {
+		ps := player.PlayerStats
+		table.Append([]string{
+			highlight(player.Nickname, tracked),
+			fmt.Sprint(ps.Kills),
+			fmt.Sprint(ps.Deaths),
+			fmt.Sprint(ps.Assists),
+			fmt.Sprintf("%.2f", ps.KDRatio),
+			fmt.Sprintf("%.1f", ps.ADR),
+			fmt.Sprintf("%.0f", ps.HeadshotsPercentage),
+			fmt.Sprint(ps.MVPs),
+		})
+	}
+	table.Render()
+	return builder.String()
+}
+
+// halfScores renders a team's score by half, with overtime only when it was played
+func halfScores(team matchStatsTeam) string {
+	ts := team.TeamStats
+	text := fmt.Sprintf("%d (%d/%d", ts.FinalScore, ts.FirstHalfScore, ts.SecondHalfScore)
+	if ts.OvertimeScore > 0 {
+		text += fmt.Sprintf(", OT %d", ts.OvertimeScore)
+	}
+	return text + ")"
+}
+
+// MatchReport renders the header and every map's scoreboards of a finished match, one message per map so a
+// best-of-three stays under Discord's message limit. The header goes with the first map.
+func MatchReport(matchID string) []string {
+	match, err := getMatch(matchID)
+	if err != nil {
+		return []string{"Match not found on FACEIT: " + matchID}
+	}
+	header := fmt.Sprintf("**%s vs %s**", match.Teams["faction1"].Name, match.Teams["faction2"].Name)
+	if match.CompetitionName != "" {
+		header += " — " + match.CompetitionName
+	}
+	if match.FinishedAt > 0 {
+		header += " — " + time.Unix(match.FinishedAt, 0).In(reportLocation()).Format("01/02 15:04")
+	}
+	if match.FaceitURL != "" {
+		header += "\n<" + strings.Replace(match.FaceitURL, "{lang}", "en", 1) + ">"
+	}
+	if match.Status != "FINISHED" {
+		return []string{header + "\nMatch status: " + match.Status + ", scoreboards are available once it has finished."}
+	}
+	stats, err := getMatchStats(match.MatchID)
+	if err != nil {
+		log.Printf("Error getting stats for match %s: %v", match.MatchID, err)
+		return []string{header + "\nCould not load the match scoreboard."}
+	}
+
+	tracked := trackedNicknames()
+	var sections []string
+	for _, round := range stats.Rounds {
+		section := "**" + round.RoundStats.Map + "**"
+		if len(stats.Rounds) > 1 {
+			section = fmt.Sprintf("**Map %d: %s**", round.MatchRound, round.RoundStats.Map)
+		}
+		for _, team := range round.Teams {
+			name := team.TeamStats.Team
+			if team.TeamID == round.RoundStats.Winner {
+				name += " (W)"
+			}
+			section += "\n" + name + " " + halfScores(team) + "\n```" + renderScoreboard(team, tracked) + "```"
+		}
+		sections = append(sections, truncateMessage(section))
+	}
+	if len(sections) == 0 {
+		return []string{header}
+	}
+	sections[0] = truncateMessage(header + "\n" + sections[0])
+	return sections
+}
+
+// respondMatchReport defers the interaction and replaces it with the match report
+func respondMatchReport(s *discordgo.Session, i *discordgo.InteractionCreate, matchID string, flags discordgo.MessageFlags) {
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: flags},
+	})
+	go func() {
+		messages := MatchReport(matchID)
+		if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
+			Content: &messages[0],
+		}); err != nil {
+			log.Printf("failed to edit response: %v", err)
+			return
+		}
+		// The other maps follow as separate messages
+		for _, content := range messages[1:] {
+			if _, err := followupMessageCreate(s, i.Interaction, true, &discordgo.WebhookParams{
+				Content: content,
+				Flags:   flags,
+			}); err != nil {
+				log.Printf("failed to send follow-up: %v", err)
+				return
+			}
+		}
+	}()
+}
+
+func handleMatch(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	matchID := parseMatchID(i.ApplicationCommandData().Options[0].StringValue())
+	respondMatchReport(s, i, matchID, 0)
+}
+
+// matchDetailsButton opens the /match scoreboard of a match from /history pages and streak announcements
+func matchDetailsButton(label, matchID string) discordgo.Button {
+	return discordgo.Button{
+		Label:    label,
+		Style:    discordgo.SecondaryButton,
+		CustomID: "match:" + matchID,
+	}
+}
+
+// handleMatchButton handles "Details" buttons, answering only the user who clicked
+func handleMatchButton(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if len(args) != 1 {
+		return
+	}
+	respondMatchReport(s, i, args[0], discordgo.MessageFlagsEphemeral)
+}
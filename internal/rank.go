@@ -0,0 +1,253 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/olekukonko/tablewriter"
+)
+
+// A player's regional and country ranking position on a given day. A position of 0 means unranked.
+type RankSnapshot struct {
+	PlayerID        string `json:"player_id"`
+	Nickname        string `json:"nickname"`
+	Region          string `json:"region"`
+	Country         string `json:"country"`
+	RegionPosition  int    `json:"region_position"`
+	CountryPosition int    `json:"country_position"`
+	TakenAt         int64  `json:"taken_at"`
+}
+
+// This struct is the layout of data/rank_snapshots.json
+type rankSnapshots struct {
+	Snapshots []RankSnapshot `json:"snapshots"`
+}
+
+const (
+	rankSnapshotsFile = "rank_snapshots.json"
+	// Rank movement compares against the newest snapshot at least this old
+	rankMovementWindow = 7 * 24 * time.Hour
+)
+
+var rankMu sync.Mutex
+
+func loadRankSnapshots() rankSnapshots {
+	snapshots := rankSnapshots{Snapshots: []RankSnapshot{}}
+	if err := readDataFile(rankSnapshotsFile, &snapshots); err != nil {
+		log.Println("Error reading", rankSnapshotsFile, err)
+	}
+	return snapshots
+}
+
+// getRankingPosition returns a player's CS2 position in a region, or in a country of that region when
+// country is set
+func getRankingPosition(playerID, region, country string) (int, error) {
+	var ranking struct {
+		Position int `json:"position"`
+	}
+	params := map[string]interface{}{"limit": 1}
+	if country != "" {
+		params["country"] = country
+	}
+	err := getFACEIT("/rankings/games/cs2/regions/"+url.PathEscape(region)+"/players/"+url.PathEscape(playerID), params, &ranking)
+	return ranking.Position, err
+}
+
+// lookupRank reads a player's current regional and country positions
+func lookupRank(profile FACEITPlayerProfile) (RankSnapshot, error) {
+	snap := RankSnapshot{
+		PlayerID: profile.PlayerID,
+		Nickname: profile.Nickname,
+		Region:   profile.Games["cs2"].Region,
+		Country:  strings.ToLower(profile.Country),
+		TakenAt:  time.Now().Unix(),
+	}
+	if snap.Region == "" {
+		return snap, fmt.Errorf("%s has no CS2 region", profile.Nickname)
+	}
+	var err error
+	if snap.RegionPosition, err = getRankingPosition(snap.PlayerID, snap.Region, ""); err != nil {
+		return snap, err
+	}
+	if snap.Country != "" {
+		if snap.CountryPosition, err = getRankingPosition(snap.PlayerID, snap.Region, snap.Country); err != nil {
+			return snap, err
+		}
+	}
+	return snap, nil
+}
+
+// rankBaselines returns, per player ID, the newest snapshot taken at least rankMovementWindow before now
+func rankBaselines(now time.Time) map[string]RankSnapshot {
+	rankMu.Lock()
+	defer rankMu.Unlock()
+	cutoff := now.Add(-rankMovementWindow).Unix()
+	baselines := make(map[string]RankSnapshot)
+	for _, snap := range loadRankSnapshots().Snapshots {
+		if snap.TakenAt > cutoff {
+			continue
+		}
+		if prev, ok := baselines[snap.PlayerID]; !ok || snap.TakenAt >= prev.TakenAt {
+			baselines[snap.PlayerID] = snap
+		}
+	}
+	return baselines
+}
+
+// TakeRankSnapshots records each tracked player's ranking once per day (in the report time zone)
+func TakeRankSnapshots() {
+	loc := reportLocation()
+	today := time.Now().In(loc).Format("2006-01-02")
+
+	rankMu.Lock()
+	taken := make(map[string]bool)
+	for _, snap := range loadRankSnapshots().Snapshots {
+		if time.Unix(snap.TakenAt, 0).In(loc).Format("2006-01-02") == today {
+			taken[snap.PlayerID] = true
+		}
+	}
+	rankMu.Unlock()
+
+	var fresh []RankSnapshot
+	for _, player := range getPlayerIDs() {
+		if taken[player.PlayerID] {
+			continue
+		}
+		profile, err := getPlayerByID(player.PlayerID)
+		if err != nil {
+			log.Printf("Error getting profile for %s: %v ... Continuing", player.PlayerName, err)
+			continue
+		}
+		snap, err := lookupRank(profile)
+		if err != nil {
+			log.Printf("Error getting ranking for %s: %v ... Continuing", player.PlayerName, err)
+			continue
+		}
+		fresh = append(fresh, snap)
+	}
+	if len(fresh) == 0 {
+		return
+	}
+
+	rankMu.Lock()
+	defer rankMu.Unlock()
+	snapshots := loadRankSnapshots()
+	snapshots.Snapshots = append(snapshots.Snapshots, fresh...)
+	if err := writeDataFile(rankSnapshotsFile, snapshots); err != nil {
+		log.Println("Error writing", rankSnapshotsFile, err)
+	}
+	log.Println("Rank snapshots taken for", len(fresh), "players")
+}
+
+// formatPosition renders a ranking position, 0 being unranked
+func formatPosition(position int) string {
+	if position <= 0 {
+		return "-"
+	}
+	return "#" + strconv.Itoa(position)
+}
+
+// rankMovement renders the change from a week ago; climbing the ranking means a lower position number
+func rankMovement(previous, current int) string {
+	if previous <= 0 || current <= 0 {
+		return "-"
+	}
+	switch diff := previous - current; {
+	case diff > 0:
+		return "▲" + strconv.Itoa(diff)
+	case diff < 0:
+		return "▼" + strconv.Itoa(-diff)
+	}
+	return "="
+}
+
+func renderRanks(ranks []RankSnapshot, baselines map[string]RankSnapshot) string {
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	table.Header([]string{"NAME", "REGION", "POS", "7D", "COUNTRY", "POS", "7D"})
+	for _, rank := range ranks {
+		baseline := baselines[rank.PlayerID]
+		table.Append([]string{
+			rank.Nickname,
+			rank.Region,
+			formatPosition(rank.RegionPosition),
+			rankMovement(baseline.RegionPosition, rank.RegionPosition),
+			strings.ToUpper(rank.Country),
+			formatPosition(rank.CountryPosition),
+			rankMovement(baseline.CountryPosition, rank.CountryPosition),
+		})
+	}
+	table.Render()
+	return builder.String()
+}
+
+// PlayerRank looks up one player by nickname
+func PlayerRank(nickname string) string {
+	profile, err := getPlayerByNickname(nickname)
+	if err != nil {
+		return "Player not found on FACEIT: " + nickname
+	}
+	rank, err := lookupRank(profile)
+	if err != nil {
+		log.Printf("Error getting ranking for %s: %v", nickname, err)
+		return "Could not load the ranking for " + profile.Nickname + "."
+	}
+	return "```" + renderRanks([]RankSnapshot{rank}, rankBaselines(time.Now())) + "```"
+}
+
+// TrackedPlayerRanks looks up every tracked player, best regional position first
+func TrackedPlayerRanks() string {
+	var ranks []RankSnapshot
+	for _, player := range getPlayerIDs() {
+		profile, err := getPlayerByID(player.PlayerID)
+		if err != nil {
+			log.Printf("Error getting profile for %s: %v ... Continuing", player.PlayerName, err)
+			continue
+		}
+		rank, err := lookupRank(profile)
+		if err != nil {
+			log.Printf("Error getting ranking for %s: %v ... Continuing", player.PlayerName, err)
+			continue
+		}
+		ranks = append(ranks, rank)
+	}
+	if len(ranks) == 0 {
+		return "No rankings found for the tracked players."
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		if (ranks[i].RegionPosition > 0) != (ranks[j].RegionPosition > 0) {
+			return ranks[i].RegionPosition > 0
+		}
+		return ranks[i].RegionPosition < ranks[j].RegionPosition
+	})
+	return "**Rankings**\n```" + renderRanks(ranks, rankBaselines(time.Now())) + "```"
+}
+
+func handleRank(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := optionsByName(i.ApplicationCommandData().Options)
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	go func() {
+		var content string
+		if opt, ok := opts["player"]; ok {
+			content = PlayerRank(strings.TrimSpace(opt.StringValue()))
+		} else {
+			content = TrackedPlayerRanks()
+		}
+		content = truncateMessage(content)
+		if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		}); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// A group's sorted summary rows for one window
+type groupReport struct {
+	Group reportGroup
+	Rows  []*MatchHistory
+}
+
+// buildGroupReports aggregates and sorts every group's rows for the window, applying the report slot's
+// filter the way FACEITInit does. Groups with an invalid filter are logged and skipped.
+func buildGroupReports(window apiWindow, slot, sortKey string) []groupReport {
+	var reports []groupReport
+	for _, group := range reportGroups(summaryGuildID()) {
+		filter, err := compileFilter(reportFilterExpression(slot, group.Filter))
+		if err != nil {
+			log.Printf("Invalid %s filter%s: %v", slot, group.markerSuffix(), err)
+			continue
+		}
+		rows := aggregateMatchHistory(group.Players, filter, ToUnixMillis(window.Start), ToUnixMillis(window.End))
+		sortMatchHistory(rows, sortKey)
+		reports = append(reports, groupReport{Group: group, Rows: rows})
+	}
+	return reports
+}
+
+// Report renders the summaries of a window for the terminal, as the tables posted to Discord or as the
+// JSON of /api/reports/weekly. The window is "last-week", "current-week", or anything resolveReportWindow
+// accepts. Rank movement is shown against the positions the bot recorded, without recording new ones.
+func Report(windowName, format string) (string, error) {
+	if format != "" && format != "table" && format != "json" {
+		return "", fmt.Errorf("unknown format %q, use table or json", format)
+	}
+	window, slot, err := resolveReportWindow(strings.TrimSuffix(windowName, "-week"), "last")
+	if err != nil {
+		return "", err
+	}
+	sortKey := reportSortKey(slot, "")
+	reports := buildGroupReports(window, slot, sortKey)
+
+	if format == "json" {
+		report := apiReport{Window: window, Sort: sortKey, Groups: []apiReportGroup{}}
+		banned := activeBans()
+		for _, gr := range reports {
+			out := apiReportGroup{Name: gr.Group.Name, Rows: []apiRow{}}
+			for n, mh := range gr.Rows {
+				out.Rows = append(out.Rows, toAPIRow(mh, n+1, banned))
+			}
+			report.Groups = append(report.Groups, out)
+		}
+		data, err := json.MarshalIndent(report, "", "    ")
+		return string(data) + "\n", err
+	}
+
+	// Positions are only recorded for whole reporting periods
+	var previousStart int64
+	if window.Name == "current" || window.Name == "last" {
+		previousStart = CurrentWeekWindow(window.Start.Add(-time.Millisecond)).StartMillis()
+	}
+	var b strings.Builder
+	for _, gr := range reports {
+		var previous map[string]int
+		if previousStart != 0 {
+			previous = rankPositions(gr.Group.Name, slot, sortKey, previousStart)
+		}
+		fmt.Fprintf(&b, "Match History%s: %s -> %s%s\n", gr.Group.markerSuffix(),
+			formatWindowBound(window.Start), formatWindowBound(window.End), sortCaption(sortKey))
+		b.WriteString(renderMatchHistory(gr.Rows, previous, nil))
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Maps FACEIT skill levels and ELO bands to Discord roles
+type RoleMapping struct {
+	Levels   map[string]string `json:"levels"` // key: skill level "1".."10", value: role ID
+	ELOBands []ELOBand         `json:"elo_bands"`
+}
+
+// An ELO band grants RoleID to players with Min <= ELO <= Max. Max of 0 means no upper bound.
+type ELOBand struct {
+	Min    int    `json:"min"`
+	Max    int    `json:"max"` // 0: unbounded
+	RoleID string `json:"role_id"`
+}
+
+// label renders the band's range as Min–Max, or Min+ when it has no upper bound
+func (band ELOBand) label() string {
+	if band.Max > 0 {
+		return fmt.Sprintf("%d–%d", band.Min, band.Max)
+	}
+	return fmt.Sprintf("%d+", band.Min)
+}
+
+func (m RoleMapping) empty() bool {
+	return len(m.Levels) == 0 && len(m.ELOBands) == 0
+}
+
+// managedRoles returns every role ID the mapping controls. Only these roles are ever added or removed.
+func (m RoleMapping) managedRoles() map[string]bool {
+	managed := make(map[string]bool)
+	for _, roleID := range m.Levels {
+		managed[roleID] = true
+	}
+	for _, band := range m.ELOBands {
+		managed[band.RoleID] = true
+	}
+	return managed
+}
+
+// rolesFor returns the role IDs a player with this snapshot should hold
+func (m RoleMapping) rolesFor(snap ELOSnapshot) map[string]bool {
+	roles := make(map[string]bool)
+	if roleID, ok := m.Levels[strconv.Itoa(snap.SkillLevel)]; ok {
+		roles[roleID] = true
+	}
+	for _, band := range m.ELOBands {
+		if snap.Elo >= band.Min && (band.Max == 0 || snap.Elo <= band.Max) {
+			roles[band.RoleID] = true
+		}
+	}
+	return roles
+}
+
+// A pending role update for one linked member
+type roleChange struct {
+	DiscordID string
+	Nickname  string
+	Level     int
+	Elo       int
+	Add       []string
+	Remove    []string
+}
+
+// planRoleSync works out which managed roles each verified, linked guild member should gain or lose
+func planRoleSync(s *discordgo.Session, guildID string, mapping RoleMapping, latest map[string]ELOSnapshot) []roleChange {
+	managed := mapping.managedRoles()
+	var changes []roleChange
+	for _, link := range getVerifiedLinks() {
+		snap, ok := latest[link.PlayerID]
+		if !ok {
+			continue
+		}
+		member, err := s.GuildMember(guildID, link.DiscordID)
+		if err != nil {
+			// Not a member of this guild
+			continue
+		}
+		want := mapping.rolesFor(snap)
+		change := roleChange{DiscordID: link.DiscordID, Nickname: snap.Nickname, Level: snap.SkillLevel, Elo: snap.Elo}
+		has := make(map[string]bool)
+		for _, roleID := range member.Roles {
+			has[roleID] = true
+			if managed[roleID] && !want[roleID] {
+				change.Remove = append(change.Remove, roleID)
+			}
+		}
+		for roleID := range want {
+			if !has[roleID] {
+				change.Add = append(change.Add, roleID)
+			}
+		}
+		if len(change.Add) > 0 || len(change.Remove) > 0 {
+			sort.Strings(change.Add)
+			sort.Strings(change.Remove)
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return strings.ToLower(changes[i].Nickname) < strings.ToLower(changes[j].Nickname)
+	})
+	return changes
+}
+
+func applyRoleSync(s *discordgo.Session, guildID string, changes []roleChange) {
+	for _, change := range changes {
+		if dryRun {
+			printDryRun(fmt.Sprintf("roles of %s (%s) in guild %s", change.Nickname, change.DiscordID, guildID),
+				&discordgo.MessageSend{Content: fmt.Sprintf("add: %v\nremove: %v", change.Add, change.Remove)})
+			continue
+		}
+		for _, roleID := range change.Add {
+			if err := s.GuildMemberRoleAdd(guildID, change.DiscordID, roleID); err != nil {
+				log.Printf("Error adding role %s to %s: %v", roleID, change.Nickname, err)
+			}
+		}
+		for _, roleID := range change.Remove {
+			if err := s.GuildMemberRoleRemove(guildID, change.DiscordID, roleID); err != nil {
+				log.Printf("Error removing role %s from %s: %v", roleID, change.Nickname, err)
+			}
+		}
+	}
+}
+
+// SyncLevelRoles applies every guild's role mapping to its linked members using the given snapshots
+func SyncLevelRoles(s *discordgo.Session, latest map[string]ELOSnapshot) {
+	for guildID, settings := range allGuildSettings() {
+		if settings.Roles.empty() {
+			continue
+		}
+		changes := planRoleSync(s, guildID, settings.Roles, latest)
+		applyRoleSync(s, guildID, changes)
+		log.Printf("Role sync for guild %s: %d members updated", guildID, len(changes))
+	}
+}
+
+// renderRoleChanges formats a role plan as a table, resolving role IDs to names where possible
+func renderRoleChanges(s *discordgo.Session, guildID string, changes []roleChange) string {
+	if len(changes) == 0 {
+		return "All linked members already have the correct roles."
+	}
+	names := make(map[string]string)
+	if roles, err := s.GuildRoles(guildID); err == nil {
+		for _, role := range roles {
+			names[role.ID] = role.Name
+		}
+	}
+	roleNames := func(ids []string) string {
+		out := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if name, ok := names[id]; ok {
+				out = append(out, name)
+			} else {
+				out = append(out, id)
+			}
+		}
+		return strings.Join(out, ", ")
+	}
+	var builder bytes.Buffer
+	table := tablewriter.NewTable(&builder)
+	table.Header([]string{"NAME", "LVL", "ELO", "ADD", "REMOVE"})
+	for _, change := range changes {
+		table.Append([]string{change.Nickname, strconv.Itoa(change.Level), strconv.Itoa(change.Elo), roleNames(change.Add), roleNames(change.Remove)})
+	}
+	table.Render()
+	return "```" + builder.String() + "```"
+}
+
+// renderRoleMapping lists the configured level roles and ELO bands
+func renderRoleMapping(mapping RoleMapping) string {
+	if mapping.empty() {
+		return "No role mapping configured. Use `/roles set-level` or `/roles set-elo-band`."
+	}
+	var lines []string
+	for level := 1; level <= 10; level++ {
+		if roleID, ok := mapping.Levels[strconv.Itoa(level)]; ok {
+			lines = append(lines, fmt.Sprintf("Level %d → <@&%s>", level, roleID))
+		}
+	}
+	for _, band := range mapping.ELOBands {
+		lines = append(lines, fmt.Sprintf("ELO %s → <@&%s>", band.label(), band.RoleID))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func handleRoles(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionsByName(sub.Options)
+	switch sub.Name {
+	case "set-level":
+		level := strconv.FormatInt(opts["level"].IntValue(), 10)
+		roleID := opts["role"].RoleValue(nil, "").ID
+		err := updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			if settings.Roles.Levels == nil {
+				settings.Roles.Levels = map[string]string{}
+			}
+			settings.Roles.Levels[level] = roleID
+		})
+		respondRolesUpdate(s, i, err, fmt.Sprintf("Level %s now maps to <@&%s>", level, roleID))
+	case "set-elo-band":
+		band := ELOBand{Min: int(opts["min"].IntValue()), RoleID: opts["role"].RoleValue(nil, "").ID}
+		if max, ok := opts["max"]; ok {
+			band.Max = int(max.IntValue())
+		}
+		if band.Max != 0 && band.Max < band.Min {
+			respondEphemeral(s, i, "`max` must be greater than or equal to `min`.")
+			return
+		}
+		err := updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			bands := settings.Roles.ELOBands[:0]
+			for _, existing := range settings.Roles.ELOBands {
+				if existing.RoleID != band.RoleID {
+					bands = append(bands, existing)
+				}
+			}
+			settings.Roles.ELOBands = append(bands, band)
+			sort.Slice(settings.Roles.ELOBands, func(a, b int) bool {
+				return settings.Roles.ELOBands[a].Min < settings.Roles.ELOBands[b].Min
+			})
+		})
+		respondRolesUpdate(s, i, err, fmt.Sprintf("ELO band %s now maps to <@&%s>", band.label(), band.RoleID))
+	case "unset":
+		roleID := opts["role"].RoleValue(nil, "").ID
+		err := updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			for level, mapped := range settings.Roles.Levels {
+				if mapped == roleID {
+					delete(settings.Roles.Levels, level)
+				}
+			}
+			bands := settings.Roles.ELOBands[:0]
+			for _, band := range settings.Roles.ELOBands {
+				if band.RoleID != roleID {
+					bands = append(bands, band)
+				}
+			}
+			settings.Roles.ELOBands = bands
+		})
+		respondRolesUpdate(s, i, err, fmt.Sprintf("<@&%s> is no longer managed", roleID))
+	case "list":
+		respondEphemeral(s, i, renderRoleMapping(getGuildSettings(i.GuildID).Roles))
+	case "preview", "sync":
+		apply := sub.Name == "sync"
+		interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		})
+		go func() {
+			mapping := getGuildSettings(i.GuildID).Roles
+			content := renderRoleMapping(mapping)
+			if !mapping.empty() {
+				latest := latestELOSnapshots()
+				if apply || len(latest) == 0 {
+					latest = TakeELOSnapshots()
+				}
+				changes := planRoleSync(s, i.GuildID, mapping, latest)
+				if apply {
+					applyRoleSync(s, i.GuildID, changes)
+					content = fmt.Sprintf("Roles synced for %d members\n", len(changes)) + renderRoleChanges(s, i.GuildID, changes)
+				} else {
+					content = "**Dry run** — no roles were changed\n" + renderRoleChanges(s, i.GuildID, changes)
+				}
+			}
+			if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
+				Content: &content,
+			}); err != nil {
+				log.Printf("failed to edit response: %v", err)
+			}
+		}()
+	}
+}
+
+func respondRolesUpdate(s *discordgo.Session, i *discordgo.InteractionCreate, err error, success string) {
+	if err != nil {
+		log.Println("Error saving role mapping", err)
+		respondEphemeral(s, i, "Could not save the role mapping, try again later.")
+		return
+	}
+	respondEphemeral(s, i, success)
+}
@@ -0,0 +1,397 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// FACEIT orange, used as the accent color of the bot's embeds
+const faceitEmbedColor = 0xFF5500
+
+// Reported instead of "not found" when FACEIT times out, rate limits or fails, so a real player isn't
+// reported missing during an outage
+var errFACEITUnavailable = errors.New("FACEIT is unavailable, try again")
+
+// validateNewPlayer resolves a nickname on FACEIT and checks the account plays CS2 and is not already tracked,
+// either under the same nickname (case-insensitive) or under another nickname with the same player ID in roster.
+// The errors leave out the nickname, callers put it in front.
+func validateNewPlayer(nickname string, roster []FACEITPlayers) (FACEITPlayerProfile, error) {
+	nickname = strings.TrimSpace(nickname)
+	if containsName(loadPlayerJSON().PlayerName, nickname) {
+		return FACEITPlayerProfile{}, errors.New("already tracked")
+	}
+	profile, err := getPlayerByNickname(nickname)
+	if transientFACEITError(err) {
+		return profile, errFACEITUnavailable
+	}
+	if err != nil || profile.PlayerID == "" {
+		return profile, errors.New("not found on FACEIT")
+	}
+	if _, ok := profile.Games["cs2"]; !ok {
+		return profile, errors.New("no CS2 profile on FACEIT")
+	}
+	for _, player := range roster {
+		if player.PlayerID == profile.PlayerID {
+			return profile, fmt.Errorf("already tracked as %s", player.PlayerName)
+		}
+	}
+	return profile, nil
+}
+
+// addPlayerMessage is the reply to adding nickname, for validation errors and errors from addTrackedPlayer
+func addPlayerMessage(nickname string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("Cannot add %s: %v", nickname, err)
+	}
+	return "Player added: " + nickname
+}
+
+// playerEmbed renders a FACEIT profile as an embed with avatar, level, ELO and country
+func playerEmbed(profile FACEITPlayerProfile) *discordgo.MessageEmbed {
+	game := profile.Games["cs2"]
+	embed := &discordgo.MessageEmbed{
+		Title: profile.Nickname,
+		URL:   strings.ReplaceAll(profile.FaceitURL, "{lang}", "en"),
+		Color: faceitEmbedColor,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Level", Value: strconv.Itoa(game.SkillLevel), Inline: true},
+			{Name: "ELO", Value: strconv.Itoa(game.FaceitElo), Inline: true},
+			{Name: "Country", Value: strings.ToUpper(profile.Country), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: profile.PlayerID},
+	}
+	if game.Region != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Region", Value: game.Region, Inline: true})
+	}
+	if profile.Avatar != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: profile.Avatar}
+	}
+	return embed
+}
+
+// handleAddPlayer validates the nickname and replies with a preview and Confirm/Cancel buttons
+func handleAddPlayer(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	go func() {
+		edit := &discordgo.WebhookEdit{}
+		nickname := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+		profile, err := validateNewPlayer(nickname, getPlayerIDs())
+		if err != nil {
+			content := addPlayerMessage(nickname, err)
+			edit.Content = &content
+		} else {
+			content := "Add this player to the tracked list?"
+			embeds := []*discordgo.MessageEmbed{playerEmbed(profile)}
+			components := []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Confirm", Style: discordgo.SuccessButton, CustomID: "add-player:confirm:" + profile.PlayerID},
+					discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "add-player:cancel"},
+				}},
+			}
+			edit.Content = &content
+			edit.Embeds = &embeds
+			edit.Components = &components
+		}
+		if _, err := interactionResponseEdit(s, i.Interaction, edit); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+	}()
+}
+
+// handleAddPlayerButton handles the Confirm/Cancel buttons of the /add-player preview
+func handleAddPlayerButton(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	go func() {
+		content := "Cancelled."
+		if len(args) == 2 && args[0] == "confirm" {
+			// The preview already checked the player ID against the roster, only the stored names can
+			// have changed since
+			if profile, err := getPlayerByID(args[1]); transientFACEITError(err) {
+				content = addPlayerMessage(args[1], errFACEITUnavailable)
+			} else if err != nil {
+				content = addPlayerMessage(args[1], errors.New("not found on FACEIT"))
+			} else {
+				content = addPlayerMessage(profile.Nickname, addTrackedPlayer(profile.Nickname))
+			}
+		}
+		embeds := []*discordgo.MessageEmbed{}
+		components := []discordgo.MessageComponent{}
+		if _, err := interactionResponseEdit(s, i.Interaction, &discordgo.WebhookEdit{
+			Content:    &content,
+			Embeds:     &embeds,
+			Components: &components,
+		}); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+	}()
+}
+
+// Upper bound on players taken from a single import, each one costs several FACEIT API calls
+const maxRosterImport = 50
+
+// One roster row as written by /roster export
+type rosterEntry struct {
+	Nickname string `json:"nickname"`
+	PlayerID string `json:"player_id"`
+}
+
+// ImportPlayers runs every nickname through the same validation as AddPlayer and saves the accepted ones
+func ImportPlayers(nicknames []string) string {
+	roster := getPlayerIDs()
+	seen := make(map[string]bool)
+	var valid, added, rejected []string
+	for _, nickname := range nicknames {
+		nickname = strings.TrimSpace(nickname)
+		if nickname == "" || seen[strings.ToLower(nickname)] {
+			continue
+		}
+		seen[strings.ToLower(nickname)] = true
+		if len(valid)+len(rejected) == maxRosterImport {
+			rejected = append(rejected, fmt.Sprintf("Import limited to %d players, the rest were ignored", maxRosterImport))
+			break
+		}
+		profile, err := validateNewPlayer(nickname, roster)
+		if err != nil {
+			rejected = append(rejected, nickname+": "+err.Error())
+			continue
+		}
+		roster = append(roster, FACEITPlayers{PlayerName: profile.Nickname, PlayerID: profile.PlayerID})
+		valid = append(valid, profile.Nickname)
+	}
+	// Validation asks FACEIT about every name, so the file is only locked for the write
+	updatePlayerJSON(func(names *FACEITPlayerNames) bool {
+		for _, nickname := range valid {
+			if containsName(names.PlayerName, nickname) {
+				rejected = append(rejected, nickname+": already tracked")
+				continue
+			}
+			names.PlayerName = append(names.PlayerName, nickname)
+			added = append(added, nickname)
+		}
+		return len(added) > 0
+	})
+
+	content := fmt.Sprintf("Players added: %d", len(added))
+	if len(added) > 0 {
+		content += "\n" + strings.Join(added, ", ")
+	}
+	if len(rejected) > 0 {
+		content += fmt.Sprintf("\nSkipped: %d\n- %s", len(rejected), strings.Join(rejected, "\n- "))
+	}
+	return content
+}
+
+// parseRosterFile reads nicknames from an uploaded JSON or CSV file. JSON may be the faceit_player_names.json
+// layout, the /roster export layout, or a bare array of names/entries. CSV uses the first column.
+func parseRosterFile(filename string, data []byte) ([]string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if strings.HasSuffix(strings.ToLower(filename), ".json") || bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")) {
+		var wrapped struct {
+			Players json.RawMessage `json:"players"`
+		}
+		list := trimmed
+		if json.Unmarshal(trimmed, &wrapped) == nil && wrapped.Players != nil {
+			list = wrapped.Players
+		}
+		var names []string
+		if err := json.Unmarshal(list, &names); err == nil {
+			return names, nil
+		}
+		var entries []rosterEntry
+		if err := json.Unmarshal(list, &entries); err != nil {
+			return nil, fmt.Errorf("unrecognised JSON roster: %v", err)
+		}
+		for _, entry := range entries {
+			names = append(names, entry.Nickname)
+		}
+		return names, nil
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(trimmed)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV roster: %v", err)
+	}
+	var names []string
+	for i, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+		if i == 0 && (strings.EqualFold(record[0], "nickname") || strings.EqualFold(record[0], "name")) {
+			continue
+		}
+		names = append(names, record[0])
+	}
+	return names, nil
+}
+
+// downloadAttachment fetches an uploaded file, refusing anything over 1 MB
+func downloadAttachment(attachment *discordgo.MessageAttachment) ([]byte, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(attachment.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 1<<20 {
+		return nil, fmt.Errorf("file is larger than 1 MB")
+	}
+	return data, nil
+}
+
+// getTeamMemberNames returns the nicknames of a FACEIT team's members
+func getTeamMemberNames(teamID string) ([]string, error) {
+	var team struct {
+		Members []struct {
+			Nickname string `json:"nickname"`
+		} `json:"members"`
+	}
+	if err := getFACEIT("/teams/"+url.PathEscape(teamID), nil, &team); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, member := range team.Members {
+		names = append(names, member.Nickname)
+	}
+	return names, nil
+}
+
+// getHubMemberNames returns up to maxRosterImport nicknames of a FACEIT hub's members
+func getHubMemberNames(hubID string) ([]string, error) {
+	var names []string
+	for offset := 0; offset < maxRosterImport; offset += 50 {
+		var page struct {
+			Items []struct {
+				Nickname string `json:"nickname"`
+			} `json:"items"`
+		}
+		if err := getFACEIT("/hubs/"+url.PathEscape(hubID)+"/members", map[string]interface{}{
+			"offset": offset,
+			"limit":  50,
+		}, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			names = append(names, item.Nickname)
+		}
+		if len(page.Items) < 50 {
+			break
+		}
+	}
+	return names, nil
+}
+
+// ExportRoster renders the tracked players with their FACEIT IDs as a CSV or JSON file
+func ExportRoster(format string) *discordgo.File {
+	players := getPlayerIDs()
+	sort.Slice(players, func(i, j int) bool {
+		return strings.ToLower(players[i].PlayerName) < strings.ToLower(players[j].PlayerName)
+	})
+	var buf bytes.Buffer
+	if format == "csv" {
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"nickname", "player_id"})
+		for _, player := range players {
+			w.Write([]string{player.PlayerName, player.PlayerID})
+		}
+		w.Flush()
+		return &discordgo.File{Name: "roster.csv", ContentType: "text/csv", Reader: &buf}
+	}
+	export := struct {
+		Players []rosterEntry `json:"players"`
+	}{Players: []rosterEntry{}}
+	for _, player := range players {
+		export.Players = append(export.Players, rosterEntry{Nickname: player.PlayerName, PlayerID: player.PlayerID})
+	}
+	data, err := json.MarshalIndent(export, "", "    ")
+	if err != nil {
+		log.Fatal("Error marshalling roster: ", err)
+	}
+	buf.Write(data)
+	return &discordgo.File{Name: "roster.json", ContentType: "application/json", Reader: &buf}
+}
+
+func handleRoster(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	data := i.ApplicationCommandData()
+	sub := data.Options[0]
+	opts := optionsByName(sub.Options)
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	go func() {
+		edit := &discordgo.WebhookEdit{}
+		content := ""
+		switch sub.Name {
+		case "export":
+			format := "json"
+			if opt, ok := opts["format"]; ok {
+				format = opt.StringValue()
+			}
+			content = "Tracked players:"
+			edit.Files = []*discordgo.File{ExportRoster(format)}
+		case "import":
+			var nicknames []string
+			var err error
+			switch {
+			case opts["file"] != nil:
+				attachment := data.Resolved.Attachments[opts["file"].Value.(string)]
+				var raw []byte
+				if raw, err = downloadAttachment(attachment); err == nil {
+					nicknames, err = parseRosterFile(attachment.Filename, raw)
+				}
+			case opts["team_id"] != nil:
+				nicknames, err = getTeamMemberNames(opts["team_id"].StringValue())
+			case opts["hub_id"] != nil:
+				nicknames, err = getHubMemberNames(opts["hub_id"].StringValue())
+			default:
+				err = fmt.Errorf("provide one of `file`, `team_id` or `hub_id`")
+			}
+			if err != nil {
+				content = "Import failed: " + err.Error()
+			} else {
+				content = truncateMessage(ImportPlayers(nicknames))
+			}
+		}
+		edit.Content = &content
+		if _, err := interactionResponseEdit(s, i.Interaction, edit); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A summary table sort order. Rows are ordered by Value, highest first.
+type sortOrder struct {
+	Key   string
+	Name  string
+	Value func(mh *MatchHistory) float64
+}
+
+const defaultSortKey = "matches"
+
+var sortOrders = []sortOrder{
+	{"matches", "Matches", func(mh *MatchHistory) float64 { return float64(mh.Total_Matches) }},
+	{"wins", "Wins", func(mh *MatchHistory) float64 { return float64(mh.Total_Wins) }},
+	{"winrate", "Win rate", func(mh *MatchHistory) float64 {
+		if mh.Total_Matches == 0 {
+			return 0
+		}
+		return float64(mh.Total_Wins) / float64(mh.Total_Matches)
+	}},
+	{"kd", "K/D", func(mh *MatchHistory) float64 { return mh.Total_KDRatio }},
+	{"adr", "ADR", func(mh *MatchHistory) float64 { return mh.Total_ADR }},
+	{"hs", "HS%", func(mh *MatchHistory) float64 { return mh.Total_HS_Percentage }},
+	{"elo", "ELO change", func(mh *MatchHistory) float64 { return float64(mh.ELO_Change) }},
+}
+
+// This struct is the layout of data/report_ranks.json
+type reportRanks struct {
+	// key: rankWindowKey, value: player ID -> position
+	Windows map[string]map[string]int `json:"windows"`
+}
+
+const (
+	reportRanksFile = "report_ranks.json"
+	// Positions older than this are pruned, only the previous window is ever compared against
+	reportRanksMaxAge = 8 * 7 * 24 * time.Hour
+)
+
+var reportRanksMu sync.Mutex
+
+func findSortOrder(key string) (sortOrder, bool) {
+	for _, order := range sortOrders {
+		if order.Key == key {
+			return order, true
+		}
+	}
+	return sortOrder{}, false
+}
+
+// sortChoices lists the sort orders for the /sort and /refresh options
+func sortChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, order := range sortOrders {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: order.Name, Value: order.Key})
+	}
+	return choices
+}
+
+// reportSortKey returns override when set, else the report slot's configured order, else the default
+func reportSortKey(slot, override string) string {
+	if override != "" {
+		return override
+	}
+	filterMu.Lock()
+	key := loadFilterSettings().Reports[slot].Sort
+	filterMu.Unlock()
+	if _, ok := findSortOrder(key); ok {
+		return key
+	}
+	return defaultSortKey
+}
+
+// sortCaption notes a non-default order next to the summary marker
+func sortCaption(key string) string {
+	order, ok := findSortOrder(key)
+	if !ok || key == defaultSortKey {
+		return ""
+	}
+	return " (sorted by " + order.Name + ")"
+}
+
+// sortMatchHistory orders rows by the sort key. Players without matches always come last; ties fall back
+// to the original order of matches played, then nickname.
+func sortMatchHistory(rows []*MatchHistory, key string) {
+	order, ok := findSortOrder(key)
+	if !ok {
+		order, _ = findSortOrder(defaultSortKey)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if (rows[i].Total_Matches == 0) != (rows[j].Total_Matches == 0) {
+			return rows[i].Total_Matches > 0
+		}
+		if vi, vj := order.Value(rows[i]), order.Value(rows[j]); vi != vj {
+			return vi > vj
+		}
+		if rows[i].Total_Matches != rows[j].Total_Matches {
+			return rows[i].Total_Matches > rows[j].Total_Matches
+		}
+		return strings.ToLower(rows[i].Nickname) < strings.ToLower(rows[j].Nickname)
+	})
+}
+
+// rankWindowKey identifies a group's table for one report slot, window and sort order. The slot keeps the
+// current-week and last-week tables of the same window apart, since their filters can differ.
+func rankWindowKey(group, slot, sortKey string, windowStart int64) string {
+	return group + "|" + slot + "|" + sortKey + "|" + strconv.FormatInt(windowStart, 10)
+}
+
+func loadReportRanks() reportRanks {
+	ranks := reportRanks{Windows: map[string]map[string]int{}}
+	if err := readDataFile(reportRanksFile, &ranks); err != nil {
+		log.Println("Error reading", reportRanksFile, err)
+	}
+	if ranks.Windows == nil {
+		ranks.Windows = map[string]map[string]int{}
+	}
+	return ranks
+}
+
+// rankPositions returns the positions recorded for a window, empty if the window was never reported
+func rankPositions(group, slot, sortKey string, windowStart int64) map[string]int {
+	reportRanksMu.Lock()
+	defer reportRanksMu.Unlock()
+	return loadReportRanks().Windows[rankWindowKey(group, slot, sortKey, windowStart)]
+}
+
+// recordRankPositions stores the positions of sorted rows for a window, replacing earlier ones. Players
+// without matches are not ranked.
+func recordRankPositions(group, slot, sortKey string, windowStart int64, rows []*MatchHistory) {
+	positions := make(map[string]int)
+	for n, mh := range rows {
+		if mh.Total_Matches > 0 {
+			positions[mh.PlayerID] = n + 1
+		}
+	}
+	reportRanksMu.Lock()
+	defer reportRanksMu.Unlock()
+	ranks := loadReportRanks()
+	cutoff := ToUnixMillis(time.Now().Add(-reportRanksMaxAge))
+	for key := range ranks.Windows {
+		start, err := strconv.ParseInt(key[strings.LastIndex(key, "|")+1:], 10, 64)
+		if err != nil || start < cutoff {
+			delete(ranks.Windows, key)
+		}
+	}
+	ranks.Windows[rankWindowKey(group, slot, sortKey, windowStart)] = positions
+	if err := writeDataFile(reportRanksFile, ranks); err != nil {
+		log.Println("Error writing", reportRanksFile, err)
+	}
+}
+
+// formatRank renders a row's position with its movement since the previous window
+func formatRank(position int, previous map[string]int, mh *MatchHistory) string {
+	if mh.Total_Matches == 0 {
+		return "-"
+	}
+	label := strconv.Itoa(position)
+	before, ok := previous[mh.PlayerID]
+	switch {
+	case !ok:
+		return label
+	case before > position:
+		return fmt.Sprintf("%s ▲%d", label, before-position)
+	case before < position:
+		return fmt.Sprintf("%s ▼%d", label, position-before)
+	}
+	return label + " ="
+}
+
+func handleSort(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	opts := optionsByName(i.ApplicationCommandData().Options)
+	slot := opts["report"].StringValue()
+	key := opts["by"].StringValue()
+	order, ok := findSortOrder(key)
+	if !ok {
+		respondEphemeral(s, i, "Unknown sort order: "+key)
+		return
+	}
+	if err := updateFilterSettings(func(settings *filterSettings) {
+		cfg := settings.Reports[slot]
+		cfg.Sort = key
+		settings.Reports[slot] = cfg
+	}); err != nil {
+		log.Println("Error saving report settings", err)
+		respondEphemeral(s, i, "Could not save the sort order, try again later.")
+		return
+	}
+	respondEphemeral(s, i, fmt.Sprintf("The %s table is sorted by %s from the next refresh.", slot, order.Name))
+}
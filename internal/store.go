@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Directory holding the bot's JSON state files
+const dataDir = "data"
+
+// readDataFile decodes data/<name> into v. A missing file is not an error and leaves v untouched.
+func readDataFile(name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(dataDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeDataFile persists v to data/<name> using the same indentation as faceit_player_names.json
+func writeDataFile(name string, v interface{}) error {
+	if dryRun {
+		log.Println("Dry run: not writing", filepath.Join(dataDir, name))
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dataDir, name), data, 0644)
+}
@@ -0,0 +1,297 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Win/loss streaks over a run of matches. Current is positive for a win streak and negative for a loss streak.
+type streaks struct {
+	Current     int
+	LongestWin  int
+	LongestLoss int
+}
+
+// Per-guild streak announcement settings. Announcements are off while MinWins is 0.
+type StreakAlertSettings struct {
+	MinWins int `json:"min_wins,omitempty"`
+}
+
+// This struct is the layout of data/streaks.json
+type streakAnnouncements struct {
+	Announced map[string]int `json:"announced"` // key: player ID, value: last announced win streak
+}
+
+const (
+	streaksFile = "streaks.json"
+	// Matches read back per player for /profile and streak announcements
+	streakLookback = 100
+)
+
+var streaksMu sync.Mutex
+
+// Streaks over the matches before a point in time, see streakBefore
+var (
+	streaksBefore   = make(map[string]int) // key: player ID and unix millis
+	streaksBeforeMu sync.Mutex
+)
+
+// streakBefore returns a player's current streak over all matches that finished before t (unix millis),
+// reading at most one page of matches back. Answers for the past can't change, so they are cached.
+func streakBefore(playerID string, t int64) int {
+	key := playerID + ":" + strconv.FormatInt(t, 10)
+	streaksBeforeMu.Lock()
+	streak, ok := streaksBefore[key]
+	streaksBeforeMu.Unlock()
+	if ok {
+		return streak
+	}
+	var list playerStatsList
+	if err := getFACEIT("/players/"+playerID+"/games/cs2/stats", map[string]interface{}{
+		"to":     t,
+		"offset": 0,
+		"limit":  statsPageSize,
+	}, &list); err != nil {
+		log.Printf("Error getting matches of %s before %d: %v", playerID, t, err)
+		return 0
+	}
+	var matches []Stats
+	for _, it := range list.Items {
+		if it.Stats.MatchFinishedAt < t {
+			matches = append(matches, it.Stats)
+		}
+	}
+	streak = computeStreaks(matches).Current
+	if t <= time.Now().UnixMilli() {
+		streaksBeforeMu.Lock()
+		if len(streaksBefore) > 10000 {
+			clear(streaksBefore)
+		}
+		streaksBefore[key] = streak
+		streaksBeforeMu.Unlock()
+	}
+	return streak
+}
+
+// currentStreak is a player's streak at the end of a window, over every match like /profile and the
+// announcements, not just the ones a report filter counts. windowMatches are all the player's matches in
+// [start, end); only a run that covers the whole window needs the matches before it.
+func currentStreak(playerID string, windowMatches []Stats, start, end int64) int {
+	var inWindow []Stats
+	for _, match := range windowMatches {
+		if match.MatchFinishedAt >= start && match.MatchFinishedAt < end {
+			inWindow = append(inWindow, match)
+		}
+	}
+	current := computeStreaks(inWindow).Current
+	if current != len(inWindow) && -current != len(inWindow) {
+		return current
+	}
+	before := streakBefore(playerID, start)
+	if current == 0 || (current > 0) == (before > 0) {
+		return current + before
+	}
+	return current
+}
+
+// computeStreaks walks the matches in the order they finished
+func computeStreaks(matches []Stats) streaks {
+	ordered := make([]Stats, len(matches))
+	copy(ordered, matches)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].MatchFinishedAt < ordered[j].MatchFinishedAt })
+
+	var st streaks
+	for _, match := range ordered {
+		if match.Result == 1 {
+			if st.Current < 0 {
+				st.Current = 0
+			}
+			st.Current++
+			if st.Current > st.LongestWin {
+				st.LongestWin = st.Current
+			}
+		} else {
+			if st.Current > 0 {
+				st.Current = 0
+			}
+			st.Current--
+			if -st.Current > st.LongestLoss {
+				st.LongestLoss = -st.Current
+			}
+		}
+	}
+	return st
+}
+
+// formatStreak renders a current streak as W3 / L2
+func formatStreak(current int) string {
+	switch {
+	case current > 0:
+		return "W" + strconv.Itoa(current)
+	case current < 0:
+		return "L" + strconv.Itoa(-current)
+	}
+	return "-"
+}
+
+func allMatches(Stats) bool { return true }
+
+// CheckStreakAlerts announces tracked players whose current win streak reached the configured minimum, and
+// again each time the streak grows. A streak is forgotten once it falls below the minimum.
+func CheckStreakAlerts(s *discordgo.Session) {
+	minWins := getGuildSettings(reportGuildID(s)).StreakAlerts.MinWins
+	if minWins <= 0 || config.Discord.UpdateChannelID == "" {
+		return
+	}
+	current := make(map[string]int)
+	nicknames := make(map[string]string)
+	latest := make(map[string]string) // key: player ID, value: match ID
+	for _, player := range getPlayerIDs() {
+		matches, err := getRecentMatches(player.PlayerID, streakLookback, allMatches)
+		if err != nil {
+			log.Printf("Error getting recent matches for %s: %v ... Continuing", player.PlayerName, err)
+			continue
+		}
+		current[player.PlayerID] = computeStreaks(matches).Current
+		nicknames[player.PlayerID] = player.PlayerName
+		if len(matches) > 0 {
+			latest[player.PlayerID] = matches[0].MatchID
+		}
+	}
+
+	streaksMu.Lock()
+	defer streaksMu.Unlock()
+	state := streakAnnouncements{Announced: map[string]int{}}
+	if err := readDataFile(streaksFile, &state); err != nil {
+		log.Println("Error reading", streaksFile, err)
+	}
+	if state.Announced == nil {
+		state.Announced = map[string]int{}
+	}
+	var announced []string // player IDs
+	for playerID, streak := range current {
+		if streak < minWins {
+			delete(state.Announced, playerID)
+			continue
+		}
+		if streak > state.Announced[playerID] {
+			announced = append(announced, playerID)
+			state.Announced[playerID] = streak
+		}
+	}
+	if err := writeDataFile(streaksFile, state); err != nil {
+		log.Println("Error writing", streaksFile, err)
+	}
+	if len(announced) == 0 {
+		return
+	}
+	sort.Slice(announced, func(i, j int) bool {
+		return strings.ToLower(nicknames[announced[i]]) < strings.ToLower(nicknames[announced[j]])
+	})
+	// Each player gets a Details button for the match that extended the streak, up to Discord's 5 rows
+	var lines []string
+	var buttons []discordgo.MessageComponent
+	for _, playerID := range announced {
+		lines = append(lines, fmt.Sprintf("**%s** has won %d matches in a row!", nicknames[playerID], current[playerID]))
+		if matchID := latest[playerID]; matchID != "" && len(buttons) < 5*buttonsPerRow {
+			buttons = append(buttons, matchDetailsButton(nicknames[playerID], matchID))
+		}
+	}
+	var rows []discordgo.MessageComponent
+	for len(buttons) > 0 {
+		n := buttonsPerRow
+		if len(buttons) < n {
+			n = len(buttons)
+		}
+		rows = append(rows, discordgo.ActionsRow{Components: buttons[:n]})
+		buttons = buttons[n:]
+	}
+	sendMessage(s, config.Discord.UpdateChannelID, &discordgo.MessageSend{
+		Content:    truncateMessage("**Win Streak**\n" + strings.Join(lines, "\n")),
+		Components: rows,
+	})
+}
+
+// ProfileEmbed is the /add-player preview card plus the player's recent form and streaks
+func ProfileEmbed(nickname string) (*discordgo.MessageEmbed, error) {
+	profile, err := getPlayerByNickname(nickname)
+	if err != nil {
+		return nil, fmt.Errorf("player not found on FACEIT: %s", nickname)
+	}
+	embed := playerEmbed(profile)
+	matches, err := getRecentMatches(profile.PlayerID, streakLookback, allMatches)
+	if err != nil {
+		log.Printf("Error getting recent matches for %s: %v", nickname, err)
+		return embed, nil
+	}
+	st := computeStreaks(matches)
+	var form []string
+	for _, match := range matches {
+		if len(form) == 5 {
+			break
+		}
+		if match.Result == 1 {
+			form = append(form, "W")
+		} else {
+			form = append(form, "L")
+		}
+	}
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{Name: "Current streak", Value: formatStreak(st.Current), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Longest win streak", Value: strconv.Itoa(st.LongestWin), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Longest loss streak", Value: strconv.Itoa(st.LongestLoss), Inline: true},
+	)
+	if len(form) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Last 5", Value: strings.Join(form, " ")})
+	}
+	embed.Footer.Text = fmt.Sprintf("%s · streaks over the last %d matches", profile.PlayerID, len(matches))
+	return embed, nil
+}
+
+func handleProfile(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	nickname := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	go func() {
+		edit := &discordgo.WebhookEdit{}
+		if embed, err := ProfileEmbed(nickname); err != nil {
+			content := "Cannot show the profile: " + err.Error()
+			edit.Content = &content
+		} else {
+			embeds := []*discordgo.MessageEmbed{embed}
+			edit.Embeds = &embeds
+		}
+		if _, err := interactionResponseEdit(s, i.Interaction, edit); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+	}()
+}
+
+func handleStreakAlerts(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasManageGuildPermission(i) {
+		respondEphemeral(s, i, "You do not have permission to use this command.")
+		return
+	}
+	minWins := 0
+	if opt, ok := optionsByName(i.ApplicationCommandData().Options)["min_wins"]; ok {
+		minWins = int(opt.IntValue())
+	}
+	if err := updateGuildSettings(i.GuildID, func(settings *GuildSettings) { settings.StreakAlerts.MinWins = minWins }); err != nil {
+		log.Println("Error saving guild settings", err)
+		respondEphemeral(s, i, "Could not save the streak alert settings, try again later.")
+		return
+	}
+	if minWins == 0 {
+		respondEphemeral(s, i, "Streak announcements disabled.")
+		return
+	}
+	respondEphemeral(s, i, fmt.Sprintf("Win streaks of %d or more are announced in the update channel.", minWins))
+}
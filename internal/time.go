@@ -1,38 +1,161 @@
 package internal
 
 import (
-	"log"
-	"os"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
 func ToUnixMillis(t time.Time) int64 { return t.UTC().UnixMilli() }
 
-func CurrentWeekWindow(now time.Time) (start, end int64, human_start, human_end string) {
-	loadEnv(true)
-	location := os.Getenv("TIME_ZONE")
-	log.Println("Time zone: ", location)
-	if location == "" {
-		location = "US/Eastern"
+// reportLocation returns the configured time zone used for report windows and dates (default US/Eastern)
+func reportLocation() *time.Location {
+	return config.location
+}
+
+// A report window, from Start up to but excluding End, in the report time zone
+type ReportWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (w ReportWindow) StartMillis() int64 { return ToUnixMillis(w.Start) }
+func (w ReportWindow) EndMillis() int64   { return ToUnixMillis(w.End) }
+
+// HumanStart and HumanEnd label the window's bounds in summaries; the hour is only shown when the
+// period doesn't start at midnight
+func (w ReportWindow) HumanStart() string { return formatWindowBound(w.Start) }
+func (w ReportWindow) HumanEnd() string   { return formatWindowBound(w.End) }
+
+func formatWindowBound(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return t.Format("01/02/2006")
+	}
+	return t.Format("01/02/2006 15:04")
+}
+
+// Previous returns the period before w
+func (w ReportWindow) Previous() ReportWindow {
+	return CurrentWeekWindow(w.Start.Add(-time.Millisecond))
+}
+
+// Contains reports whether t falls inside the window
+func (w ReportWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// The reporting period, resolved from PeriodConfig:
+//
+//	start_day   weekday the period starts on (default monday)
+//	start_hour  hour of the day the period starts at, 0-23 (default 0)
+//	mode        weekly, biweekly or custom (default weekly)
+//	days        length of a custom period in days
+//	anchor      YYYY-MM-DD date one period starts on; biweekly and custom periods are counted from it.
+//	            Defaults to the first start_day of 2024. Weekly and biweekly anchors must fall on
+//	            start_day; a custom period starts on its anchor's weekday and ignores start_day.
+type reportPeriod struct {
+	StartDay  time.Weekday
+	StartHour int
+	Days      int
+	Anchor    time.Time // midnight UTC of the anchor's calendar date
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// parseReportPeriod checks the period settings and resolves them
+func parseReportPeriod(settings PeriodConfig) (reportPeriod, error) {
+	period := reportPeriod{StartDay: time.Monday, Days: 7}
+	var errs []error
+
+	if value := strings.ToLower(strings.TrimSpace(settings.StartDay)); value != "" {
+		if day, ok := weekdays[value]; ok {
+			period.StartDay = day
+		} else if day, ok := weekdayByPrefix(value); ok {
+			period.StartDay = day
+		} else {
+			errs = append(errs, fmt.Errorf("period.start_day (WEEK_START_DAY): not a weekday: %q", settings.StartDay))
+		}
+	}
+	if settings.StartHour < 0 || settings.StartHour > 23 {
+		errs = append(errs, fmt.Errorf("period.start_hour (WEEK_START_HOUR): must be 0-23, got %d", settings.StartHour))
+	} else {
+		period.StartHour = settings.StartHour
 	}
-	loc, _ := time.LoadLocation(location)
-	n := now.In(loc)
 
-	// Find Monday of the current week (00:00:00)
-	// Go: Monday = 1 ... Sunday = 0
-	weekday := int(n.Weekday())
-	if weekday == 0 { // Sunday -> treat as 7
-		weekday = 7
+	mode := strings.ToLower(strings.TrimSpace(settings.Mode))
+	switch mode {
+	case "", "weekly":
+	case "biweekly", "bi-weekly":
+		period.Days = 14
+	case "custom":
+		if settings.Days > 0 {
+			period.Days = settings.Days
+		} else {
+			errs = append(errs, errors.New("period.days (REPORT_PERIOD_DAYS): a custom period needs a length in days"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("period.mode (REPORT_PERIOD): must be weekly, biweekly or custom, got %q", settings.Mode))
 	}
-	// Back to Monday
-	monday := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, loc).
-		AddDate(0, 0, -(weekday - 1))
 
-	human_start = monday.Format("01/02/2006")
-	human_end = monday.AddDate(0, 0, 7).Format("01/02/2006")
+	// The first start weekday on or after Monday 2024-01-01
+	period.Anchor = time.Date(2024, 1, 1+(int(period.StartDay)-int(time.Monday)+7)%7, 0, 0, 0, 0, time.UTC)
+	if value := strings.TrimSpace(settings.Anchor); value != "" {
+		if anchor, err := time.Parse("2006-01-02", value); err != nil {
+			errs = append(errs, fmt.Errorf("period.anchor (REPORT_PERIOD_ANCHOR): %w", err))
+		} else if mode != "custom" && anchor.Weekday() != period.StartDay {
+			// The anchor decides the start weekday, so a mismatch would silently move the period
+			errs = append(errs, fmt.Errorf("period.anchor (REPORT_PERIOD_ANCHOR): %s is a %s, not the start day %s",
+				value, anchor.Weekday(), period.StartDay))
+		} else {
+			period.Anchor = anchor
+		}
+	}
+	return period, errors.Join(errs...)
+}
 
-	start = ToUnixMillis(monday)
-	end = ToUnixMillis(monday.AddDate(0, 0, 7)) // next Monday 00:00
+// weekdayByPrefix accepts abbreviations such as "tue"
+func weekdayByPrefix(value string) (time.Weekday, bool) {
+	if len(value) < 3 {
+		return 0, false
+	}
+	for name, day := range weekdays {
+		if strings.HasPrefix(name, value) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// window returns the period containing now. Periods are counted in calendar days from the anchor so they
+// keep starting at the same wall-clock hour across DST changes; a period spanning a change is an hour
+// shorter or longer.
+func (period reportPeriod) window(now time.Time, loc *time.Location) ReportWindow {
+	n := now.In(loc)
+	day := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.UTC)
+	offset := int(day.Sub(period.Anchor).Hours() / 24)
+	// Floor division, so dates before the anchor land in the right period
+	elapsed := offset % period.Days
+	if elapsed < 0 {
+		elapsed += period.Days
+	}
+	startDay := day.AddDate(0, 0, -elapsed)
+	start := time.Date(startDay.Year(), startDay.Month(), startDay.Day(), period.StartHour, 0, 0, 0, loc)
+	if start.After(n) {
+		// Today is a start day but the start hour hasn't come yet
+		startDay = startDay.AddDate(0, 0, -period.Days)
+		start = time.Date(startDay.Year(), startDay.Month(), startDay.Day(), period.StartHour, 0, 0, 0, loc)
+	}
+	endDay := startDay.AddDate(0, 0, period.Days)
+	end := time.Date(endDay.Year(), endDay.Month(), endDay.Day(), period.StartHour, 0, 0, 0, loc)
+	return ReportWindow{Start: start, End: end}
+}
 
-	return start, end, human_start, human_end
+// CurrentWeekWindow returns the reporting period containing now, weekly from Monday 00:00 in the configured
+// time zone unless configured otherwise (see reportPeriod)
+func CurrentWeekWindow(now time.Time) ReportWindow {
+	return config.period.window(now, config.location)
 }
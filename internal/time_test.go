@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReportPeriodWindow(t *testing.T) {
+	eastern, err := time.LoadLocation("US/Eastern")
+	if err != nil {
+		t.Skip("time zone data not available:", err)
+	}
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, eastern)
+	}
+
+	tests := []struct {
+		name     string
+		settings PeriodConfig
+		now      time.Time
+		want     ReportWindow
+		previous ReportWindow
+		hours    float64
+	}{
+		{
+			name:     "weekly over spring forward",
+			now:      at(2026, time.March, 8, 12),
+			want:     ReportWindow{Start: at(2026, time.March, 2, 0), End: at(2026, time.March, 9, 0)},
+			previous: ReportWindow{Start: at(2026, time.February, 23, 0), End: at(2026, time.March, 2, 0)},
+			hours:    7*24 - 1,
+		},
+		{
+			name:     "weekly over fall back",
+			now:      at(2026, time.November, 1, 12),
+			want:     ReportWindow{Start: at(2026, time.October, 26, 0), End: at(2026, time.November, 2, 0)},
+			previous: ReportWindow{Start: at(2026, time.October, 19, 0), End: at(2026, time.October, 26, 0)},
+			hours:    7*24 + 1,
+		},
+		{
+			name:     "tuesday 18:00 start",
+			settings: PeriodConfig{StartDay: "tuesday", StartHour: 18},
+			now:      at(2026, time.October, 21, 10),
+			want:     ReportWindow{Start: at(2026, time.October, 20, 18), End: at(2026, time.October, 27, 18)},
+			previous: ReportWindow{Start: at(2026, time.October, 13, 18), End: at(2026, time.October, 20, 18)},
+			hours:    7 * 24,
+		},
+		{
+			name:     "start day before the start hour",
+			settings: PeriodConfig{StartDay: "tue", StartHour: 18},
+			now:      at(2026, time.October, 20, 17),
+			want:     ReportWindow{Start: at(2026, time.October, 13, 18), End: at(2026, time.October, 20, 18)},
+			previous: ReportWindow{Start: at(2026, time.October, 6, 18), End: at(2026, time.October, 13, 18)},
+			hours:    7 * 24,
+		},
+		{
+			name:     "start day at the start hour",
+			settings: PeriodConfig{StartDay: "tue", StartHour: 18},
+			now:      at(2026, time.October, 20, 18),
+			want:     ReportWindow{Start: at(2026, time.October, 20, 18), End: at(2026, time.October, 27, 18)},
+			previous: ReportWindow{Start: at(2026, time.October, 13, 18), End: at(2026, time.October, 20, 18)},
+			hours:    7 * 24,
+		},
+		{
+			name:     "biweekly over spring forward",
+			settings: PeriodConfig{Mode: "biweekly"},
+			now:      at(2026, time.March, 5, 12),
+			want:     ReportWindow{Start: at(2026, time.February, 23, 0), End: at(2026, time.March, 9, 0)},
+			previous: ReportWindow{Start: at(2026, time.February, 9, 0), End: at(2026, time.February, 23, 0)},
+			hours:    14*24 - 1,
+		},
+		{
+			name:     "biweekly over fall back",
+			settings: PeriodConfig{Mode: "bi-weekly", Anchor: "2026-10-26"},
+			now:      at(2026, time.November, 6, 12),
+			want:     ReportWindow{Start: at(2026, time.October, 26, 0), End: at(2026, time.November, 9, 0)},
+			previous: ReportWindow{Start: at(2026, time.October, 12, 0), End: at(2026, time.October, 26, 0)},
+			hours:    14*24 + 1,
+		},
+		{
+			name:     "custom 10 days with the anchor in the future",
+			settings: PeriodConfig{Mode: "custom", Days: 10, Anchor: "2027-01-01"},
+			now:      at(2026, time.October, 19, 12),
+			want:     ReportWindow{Start: at(2026, time.October, 13, 0), End: at(2026, time.October, 23, 0)},
+			previous: ReportWindow{Start: at(2026, time.October, 3, 0), End: at(2026, time.October, 13, 0)},
+			hours:    10 * 24,
+		},
+		{
+			name:     "custom 10 days over fall back",
+			settings: PeriodConfig{Mode: "custom", Days: 10, Anchor: "2027-01-01"},
+			now:      at(2026, time.November, 1, 12),
+			want:     ReportWindow{Start: at(2026, time.October, 23, 0), End: at(2026, time.November, 2, 0)},
+			previous: ReportWindow{Start: at(2026, time.October, 13, 0), End: at(2026, time.October, 23, 0)},
+			hours:    10*24 + 1,
+		},
+	}
+
+	saved := config
+	t.Cleanup(func() { config = saved })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period, err := parseReportPeriod(tt.settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config = &Config{location: eastern, period: period}
+
+			got := CurrentWeekWindow(tt.now)
+			if !got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+				t.Errorf("window = %v - %v, want %v - %v", got.Start, got.End, tt.want.Start, tt.want.End)
+			}
+			if hours := got.End.Sub(got.Start).Hours(); hours != tt.hours {
+				t.Errorf("window is %v hours long, want %v", hours, tt.hours)
+			}
+			if !got.Contains(tt.now) {
+				t.Errorf("window %v - %v doesn't contain %v", got.Start, got.End, tt.now)
+			}
+			previous := got.Previous()
+			if !previous.Start.Equal(tt.previous.Start) || !previous.End.Equal(tt.previous.End) {
+				t.Errorf("previous = %v - %v, want %v - %v", previous.Start, previous.End, tt.previous.Start, tt.previous.End)
+			}
+		})
+	}
+}
+
+func TestParseReportPeriodErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings PeriodConfig
+	}{
+		{"unknown weekday", PeriodConfig{StartDay: "someday"}},
+		{"hour out of range", PeriodConfig{StartHour: 24}},
+		{"unknown mode", PeriodConfig{Mode: "monthly"}},
+		{"custom without days", PeriodConfig{Mode: "custom"}},
+		{"bad anchor", PeriodConfig{Anchor: "01/05/2026"}},
+		{"weekly anchor off the start day", PeriodConfig{StartDay: "monday", Anchor: "2026-10-20"}},
+		{"biweekly anchor off the start day", PeriodConfig{Mode: "biweekly", Anchor: "2026-10-20"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseReportPeriod(tt.settings); err == nil {
+				t.Errorf("parseReportPeriod(%+v) succeeded, want an error", tt.settings)
+			}
+		})
+	}
+
+	if _, err := parseReportPeriod(PeriodConfig{Mode: "custom", Days: 10, Anchor: "2026-10-20"}); err != nil {
+		t.Errorf("custom period anchored off the start day: %v", err)
+	}
+}
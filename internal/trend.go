@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Metrics /trend can chart. ELO comes from the stored snapshots, the others from per-match stats
+// averaged per day.
+var trendMetrics = map[string]string{
+	"elo": "ELO",
+	"kd":  "K/D",
+	"adr": "ADR",
+	"hs":  "HS%",
+}
+
+// Chart ranges offered by /trend, in days
+var trendRanges = map[string]int{
+	"7d":   7,
+	"30d":  30,
+	"90d":  90,
+	"180d": 180,
+}
+
+const defaultTrendRange = "30d"
+
+// The /trend options holding the players to overlay, in drawing order
+var trendOptionNames = []string{"player", "player2", "player3"}
+
+// eloSeries charts the stored ELO snapshots of a player
+func eloSeries(playerID string, since time.Time) []chartPoint {
+	var points []chartPoint
+	for _, snap := range eloHistory(playerID, since) {
+		points = append(points, chartPoint{T: time.Unix(snap.TakenAt, 0), V: float64(snap.Elo)})
+	}
+	return points
+}
+
+// matchStatSeries averages a per-match metric per day in the report time zone. clipped is true when the
+// player has more matches in the range than are read, so the series starts later than since.
+func matchStatSeries(playerID, metric string, since time.Time) ([]chartPoint, bool, error) {
+	matches, clipped, err := getPlayerStatsRange(playerID, ToUnixMillis(since), ToUnixMillis(time.Now()))
+	if err != nil {
+		return nil, false, err
+	}
+	type dayTotals struct {
+		day                         time.Time
+		kills, deaths, headshots, n int
+		adr                         float64
+	}
+	loc := reportLocation()
+	days := make(map[string]*dayTotals)
+	for _, match := range matches {
+		finished := time.UnixMilli(match.MatchFinishedAt).In(loc)
+		key := finished.Format("2006-01-02")
+		totals, ok := days[key]
+		if !ok {
+			totals = &dayTotals{day: time.Date(finished.Year(), finished.Month(), finished.Day(), 12, 0, 0, 0, loc)}
+			days[key] = totals
+		}
+		totals.kills += match.Kills
+		totals.deaths += match.Deaths
+		totals.headshots += match.Headshots
+		totals.adr += match.ADR
+		totals.n++
+	}
+	var points []chartPoint
+	for _, totals := range days {
+		var v float64
+		switch metric {
+		case "kd":
+			if totals.deaths == 0 {
+				v = float64(totals.kills)
+			} else {
+				v = float64(totals.kills) / float64(totals.deaths)
+			}
+		case "adr":
+			v = totals.adr / float64(totals.n)
+		case "hs":
+			if totals.kills > 0 {
+				v = float64(totals.headshots) / float64(totals.kills) * 100
+			}
+		}
+		points = append(points, chartPoint{T: totals.day, V: v})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].T.Before(points[j].T) })
+	return points, clipped, nil
+}
+
+// TrendChart builds the chart of metric for the given players over the last days. The caption names each
+// player's line color and any player without data.
+func TrendChart(nicknames []string, metric string, days int) (string, *discordgo.File) {
+	since := time.Now().AddDate(0, 0, -days)
+	var series []chartSeries
+	var legend, missing, clipped []string
+	for _, nickname := range nicknames {
+		profile, err := getPlayerByNickname(nickname)
+		if err != nil {
+			missing = append(missing, nickname+" (not found on FACEIT)")
+			continue
+		}
+		var points []chartPoint
+		if metric == "elo" {
+			points = eloSeries(profile.PlayerID, since)
+		} else {
+			var partial bool
+			if points, partial, err = matchStatSeries(profile.PlayerID, metric, since); err != nil {
+				log.Printf("Error getting stats for %s: %v", profile.Nickname, err)
+			}
+			if partial && len(points) > 0 {
+				clipped = append(clipped, fmt.Sprintf("%s from %s", profile.Nickname, points[0].T.Format("01/02")))
+			}
+		}
+		if len(points) == 0 {
+			missing = append(missing, profile.Nickname+" (no data)")
+			continue
+		}
+		legend = append(legend, fmt.Sprintf("**%s** (%s)", profile.Nickname, chartPalette[len(series)%len(chartPalette)].Name))
+		series = append(series, chartSeries{Points: points})
+	}
+
+	note := ""
+	if len(missing) > 0 {
+		note = "No chart for " + strings.Join(missing, ", ")
+		if metric == "elo" {
+			note += ". ELO history is only recorded for tracked and linked players."
+		}
+	}
+	if len(clipped) > 0 {
+		if note != "" {
+			note += "\n"
+		}
+		note += fmt.Sprintf("Only the last %d matches per player are charted: %s", rangeMatchLimit, strings.Join(clipped, ", "))
+	}
+	if len(series) == 0 {
+		return note, nil
+	}
+	content := fmt.Sprintf("%s, last %d days: %s", trendMetrics[metric], days, strings.Join(legend, ", "))
+	if note != "" {
+		content += "\n" + note
+	}
+	image, err := renderLineChart(series)
+	if err != nil {
+		log.Println("Error rendering trend chart", err)
+		return "Could not render the chart.", nil
+	}
+	return content, &discordgo.File{
+		Name:        "trend-" + metric + ".png",
+		ContentType: "image/png",
+		Reader:      bytes.NewReader(image),
+	}
+}
+
+func handleTrend(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := optionsByName(i.ApplicationCommandData().Options)
+	var nicknames []string
+	for _, name := range trendOptionNames {
+		if opt, ok := opts[name]; ok && strings.TrimSpace(opt.StringValue()) != "" {
+			nicknames = append(nicknames, strings.TrimSpace(opt.StringValue()))
+		}
+	}
+	metric := opts["metric"].StringValue()
+	rangeName := defaultTrendRange
+	if opt, ok := opts["range"]; ok {
+		rangeName = opt.StringValue()
+	}
+	interactionRespond(s, i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	go func() {
+		content, file := TrendChart(nicknames, metric, trendRanges[rangeName])
+		edit := &discordgo.WebhookEdit{Content: &content}
+		if file != nil {
+			edit.Files = []*discordgo.File{file}
+		}
+		if _, err := interactionResponseEdit(s, i.Interaction, edit); err != nil {
+			log.Printf("failed to edit response: %v", err)
+		}
+	}()
+}
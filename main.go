@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 	"os/signal"
@@ -8,66 +9,47 @@ import (
 	"lurker-gaming-cs2-bot/internal"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/joho/godotenv"
 )
 
-var (
-	botToken        = os.Getenv("DISCORD_BOT_TOKEN")
-	applicationID   = os.Getenv("DISCORD_APP_ID")   // needed to register slash commands
-	guildID         = os.Getenv("DISCORD_GUILD_ID") // optional: register per-guild for instant availability
-	updateChannelID = os.Getenv("DISCORD_UPDATE_CHANNEL_ID")
+func main() {
+	configPath := flag.String("config", "", "YAML config file (default CONFIG_FILE or ./config.yaml)")
+	dryRun := flag.Bool("dry-run", false, "print Discord messages, presence and role changes to stdout instead of sending them")
+	flag.Parse()
 
-	gameName     = os.Getenv("FACEIT_GAME_ID")
-	faceitAppID  = os.Getenv("FACEIT_APP_ID")
-	faceitAPIKey = os.Getenv("FACEIT_API_KEY")
-)
-var s *discordgo.Session
+	cfg, err := internal.LoadConfig(*configPath)
+	if err == nil {
+		err = cfg.Validate(true)
+	}
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+	// Install the configuration before the session opens, so no handler runs against the defaults
+	internal.Configure(cfg)
+	internal.SetDryRun(*dryRun)
 
-func init() {
-	loadEnv(true)
-	var err error
-	s, err = discordgo.New("Bot " + botToken)
+	s, err := discordgo.New("Bot " + cfg.Discord.Token)
 	if err != nil {
 		log.Fatal("Error creating Discord session: ", err)
 	}
 	log.Println("Discord session created")
-	if botToken == "" || applicationID == "" {
-		log.Fatal("Missing required env: DISCORD_BOT_TOKEN, DISCORD_APP_ID")
-	}
-}
 
-func loadEnv(debug bool) {
-	if debug {
-		log.Println("Loading environment variables...")
-		// load .env file from ./.env
-		godotenv.Load("./.env")
-
-		botToken = os.Getenv("DISCORD_BOT_TOKEN")
-		applicationID = os.Getenv("DISCORD_APP_ID")
-		guildID = os.Getenv("DISCORD_GUILD_ID")
-		updateChannelID = os.Getenv("DISCORD_UPDATE_CHANNEL_ID")
-		gameName = os.Getenv("FACEIT_GAME_ID")
-		faceitAppID = os.Getenv("FACEIT_APP_ID")
-		faceitAPIKey = os.Getenv("FACEIT_API_KEY")
-
-	} else {
-		log.Println("Environment variables loaded")
-	}
-}
-
-func main() {
 	// Open Discord session
 	s.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
 		log.Println("Discord bot connected")
 	})
-	err := s.Open()
+	err = s.Open()
 	if err != nil {
-		log.Fatalf("Error opening Discord session: ", err)
+		log.Fatal("Error opening Discord session: ", err)
+	}
+	if err := cfg.ValidateChannels(s); err != nil {
+		log.Fatal("Invalid configuration: ", err)
 	}
 	internal.BotInit(s)
 	// Start FACEIT hourly refresher
 	stopCh := make(chan struct{})
 	go internal.StartFACEITRefresher(s, stopCh)
+	// Optional HTTP API, only when api.addr is set
+	go internal.StartAPIServer(stopCh)
 
 	defer s.Close()
 